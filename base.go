@@ -1,7 +1,4 @@
 package chash4go
 
-import (
-	"go_lib/logging"
-)
-
-var logger logging.Logger = logging.GetSimpleLogger()
+// The package logger which is used by the rings & checkers without their own loggers.
+var logger Logger = NopLogger{}
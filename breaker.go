@@ -0,0 +1,205 @@
+package chash4go
+
+import (
+	"sync"
+	"time"
+)
+
+// The defaults of circuit breakers.
+const (
+	DEFAULT_BREAKER_WINDOW       time.Duration = 10 * time.Second
+	DEFAULT_BREAKER_MIN_REQUESTS int           = 20
+	DEFAULT_FAILURE_RATE         float64       = 50
+	DEFAULT_COOL_DOWN            time.Duration = 30 * time.Second
+	DEFAULT_HALF_OPEN_REQUESTS   int           = 1
+)
+
+type BreakerState string
+
+// Circuit breaker state
+const (
+	BREAKER_CLOSED    BreakerState = "CLOSED"
+	BREAKER_OPEN      BreakerState = "OPEN"
+	BREAKER_HALF_OPEN BreakerState = "HALF_OPEN"
+)
+
+/*
+ * The per-target circuit breakers which are fed by 'ReportResult'.
+ * The breaker of target is opened if the failure rate (in percent) of the
+ * requests in a window reaches 'FailureRate', and there are at least
+ * 'MinRequests' requests in the window. The lookups skip the target whose
+ * breaker is open, and fall through to the next target on the ring. After
+ * 'CoolDown', the breaker is half-open and lets 'HalfOpenRequests' requests
+ * through. It is closed if all of them succeed, and opened again otherwise.
+ */
+type CircuitBreaker struct {
+	Window           time.Duration
+	MinRequests      int
+	FailureRate      float64
+	CoolDown         time.Duration
+	HalfOpenRequests int
+}
+
+// Fill the zero fields with the defaults, and validate the breaker.
+func (self *CircuitBreaker) normalize() error {
+	if self.Window == 0 {
+		self.Window = DEFAULT_BREAKER_WINDOW
+	}
+	if self.MinRequests == 0 {
+		self.MinRequests = DEFAULT_BREAKER_MIN_REQUESTS
+	}
+	if self.FailureRate == 0 {
+		self.FailureRate = DEFAULT_FAILURE_RATE
+	}
+	if self.CoolDown == 0 {
+		self.CoolDown = DEFAULT_COOL_DOWN
+	}
+	if self.HalfOpenRequests == 0 {
+		self.HalfOpenRequests = DEFAULT_HALF_OPEN_REQUESTS
+	}
+	switch {
+	case self.Window < 0:
+		return &ArgumentError{"Window", self.Window, "It should be greater than 0."}
+	case self.MinRequests < 0:
+		return &ArgumentError{"MinRequests", self.MinRequests, "It should be greater than 0."}
+	case self.FailureRate < 0 || self.FailureRate > 100:
+		return &ArgumentError{"FailureRate", self.FailureRate, "It should be in (0, 100]."}
+	case self.CoolDown < 0:
+		return &ArgumentError{"CoolDown", self.CoolDown, "It should be greater than 0."}
+	case self.HalfOpenRequests < 0:
+		return &ArgumentError{"HalfOpenRequests", self.HalfOpenRequests, "It should be greater than 0."}
+	}
+	return nil
+}
+
+// The circuit breaker of a target.
+type targetBreaker struct {
+	lock        sync.Mutex
+	state       BreakerState
+	windowStart time.Time
+	successes   int
+	failures    int
+	changedTime time.Time
+	probes      int
+}
+
+func newTargetBreaker(now time.Time) *targetBreaker {
+	return &targetBreaker{state: BREAKER_CLOSED, windowStart: now, changedTime: now}
+}
+
+// Whether a request can be sent to the target. The probe of half-open
+// breaker is counted in it. The probes are allowed again if none of them
+// is reported in the cool-down.
+func (self *targetBreaker) allow(config *CircuitBreaker, now time.Time) bool {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	switch self.state {
+	case BREAKER_OPEN:
+		if now.Sub(self.changedTime) < config.CoolDown {
+			return false
+		}
+		self.setState(BREAKER_HALF_OPEN, now)
+	case BREAKER_HALF_OPEN:
+		if self.probes >= config.HalfOpenRequests && now.Sub(self.changedTime) >= config.CoolDown {
+			self.setState(BREAKER_HALF_OPEN, now)
+		}
+	default:
+		return true
+	}
+	if self.probes >= config.HalfOpenRequests {
+		return false
+	}
+	self.probes++
+	return true
+}
+
+// Record the result of a request to the target.
+func (self *targetBreaker) record(config *CircuitBreaker, err error, now time.Time) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	switch self.state {
+	case BREAKER_CLOSED:
+		if now.Sub(self.windowStart) >= config.Window {
+			self.windowStart = now
+			self.successes, self.failures = 0, 0
+		}
+		if err != nil {
+			self.failures++
+		} else {
+			self.successes++
+		}
+		total := self.successes + self.failures
+		if total >= config.MinRequests && float64(self.failures)*100 >= config.FailureRate*float64(total) {
+			self.setState(BREAKER_OPEN, now)
+		}
+	case BREAKER_HALF_OPEN:
+		if err != nil {
+			self.setState(BREAKER_OPEN, now)
+			return
+		}
+		self.successes++
+		if self.successes >= config.HalfOpenRequests {
+			self.setState(BREAKER_CLOSED, now)
+		}
+	}
+}
+
+func (self *targetBreaker) setState(state BreakerState, now time.Time) {
+	self.state = state
+	self.changedTime = now
+	self.windowStart = now
+	self.successes, self.failures = 0, 0
+	self.probes = 0
+}
+
+func (self *targetBreaker) getState() BreakerState {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	return self.state
+}
+
+// Get the breaker of target. It is created if nonexistent.
+func (self *SimpleHashRing) getBreaker(target string, now time.Time) *targetBreaker {
+	self.breakerLock.RLock()
+	breaker, exists := self.breakerMap[target]
+	self.breakerLock.RUnlock()
+	if exists {
+		return breaker
+	}
+	self.breakerLock.Lock()
+	defer self.breakerLock.Unlock()
+	if self.breakerMap == nil {
+		self.breakerMap = make(map[string]*targetBreaker)
+	}
+	if breaker, exists = self.breakerMap[target]; !exists {
+		breaker = newTargetBreaker(now)
+		self.breakerMap[target] = breaker
+	}
+	return breaker
+}
+
+// Get the state of the circuit breaker of target. It is closed if the
+// circuit breakers are not enabled.
+func (self *SimpleHashRing) GetBreakerState(target string) BreakerState {
+	self.breakerLock.RLock()
+	breaker, exists := self.breakerMap[target]
+	self.breakerLock.RUnlock()
+	if !exists {
+		return BREAKER_CLOSED
+	}
+	return breaker.getState()
+}
+
+// Get the targets of key on the snapshot, skipping the targets whose breakers
+// do not allow requests. The error is 'ErrCircuitOpen' if no target is allowed.
+func (self *SimpleHashRing) getTargetsWithBreakers(key string, number int) ([]string, error) {
+	snapshot := self.Snapshot()
+	now := time.Now()
+	results, err := snapshot.getTargetsBy(key, number, func(target string) bool {
+		return self.getBreaker(target, now).allow(self.circuitBreaker, now)
+	})
+	if err == nil && len(key) > 0 && len(results) == 0 {
+		return nil, ErrCircuitOpen
+	}
+	return results, err
+}
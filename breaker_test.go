@@ -0,0 +1,133 @@
+package chash4go
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTargetBreaker(t *testing.T) {
+	config := &CircuitBreaker{MinRequests: 4, CoolDown: time.Minute, HalfOpenRequests: 2}
+	if err := config.normalize(); err != nil {
+		t.Errorf("Normalizing circuit breaker Error: %s", err)
+		t.FailNow()
+	}
+	now := time.Now()
+	breaker := newTargetBreaker(now)
+	requestError := errors.New("i/o timeout")
+	for _, err := range []error{requestError, nil, requestError} {
+		breaker.record(config, err, now)
+	}
+	if breaker.getState() != BREAKER_CLOSED || !breaker.allow(config, now) {
+		t.Errorf("The breaker should be closed below the min requests. (state=%s)", breaker.getState())
+		t.FailNow()
+	}
+	// The window is over.
+	now = now.Add(DEFAULT_BREAKER_WINDOW)
+	breaker.record(config, requestError, now)
+	if breaker.getState() != BREAKER_CLOSED {
+		t.Errorf("The breaker should be closed in the new window. (state=%s)", breaker.getState())
+		t.FailNow()
+	}
+	for _, err := range []error{nil, requestError, nil} {
+		breaker.record(config, err, now)
+	}
+	if breaker.getState() != BREAKER_OPEN || breaker.allow(config, now) {
+		t.Errorf("The breaker should be open at the failure rate. (state=%s)", breaker.getState())
+		t.FailNow()
+	}
+	now = now.Add(time.Minute)
+	if !breaker.allow(config, now) || !breaker.allow(config, now) || breaker.allow(config, now) {
+		t.Errorf("The half-open breaker should allow 2 probes.")
+		t.FailNow()
+	}
+	if breaker.getState() != BREAKER_HALF_OPEN {
+		t.Errorf("The breaker should be half-open after the cool-down. (state=%s)", breaker.getState())
+		t.FailNow()
+	}
+	breaker.record(config, nil, now)
+	breaker.record(config, requestError, now)
+	if breaker.getState() != BREAKER_OPEN {
+		t.Errorf("The breaker should be open again by the failed probe. (state=%s)", breaker.getState())
+		t.FailNow()
+	}
+	now = now.Add(time.Minute)
+	breaker.allow(config, now)
+	breaker.allow(config, now)
+	// The probes which are not reported are allowed again after the cool-down.
+	now = now.Add(time.Minute)
+	if !breaker.allow(config, now) {
+		t.Errorf("The lost probes should be allowed again.")
+		t.FailNow()
+	}
+	breaker.record(config, nil, now)
+	breaker.record(config, nil, now)
+	if breaker.getState() != BREAKER_CLOSED {
+		t.Errorf("The breaker should be closed by the successful probes. (state=%s)", breaker.getState())
+		t.FailNow()
+	}
+	if err := (&CircuitBreaker{FailureRate: 120}).normalize(); !errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("The breaker with failure rate over 100 should be '%v'. (but %v)", ErrInvalidArgument, err)
+		t.FailNow()
+	}
+}
+
+func TestSimpleHashRingWithCircuitBreaker(t *testing.T) {
+	servers := [...]string{"10.11.156.71:2181", "10.11.5.145:2181", "10.11.5.164:2181"}
+	shr, err := NewSimpleHashRing(WithShadowNumber(50), WithCircuitBreaker(CircuitBreaker{MinRequests: 2, CoolDown: 100 * time.Millisecond}))
+	if err != nil {
+		t.Errorf("Creating hash ring Error: %s", err)
+		t.FailNow()
+	}
+	for _, s := range servers {
+		shr.AddTarget(s)
+	}
+	key := "chash_test"
+	orderedTargets, _ := shr.Snapshot().GetTargets(key, len(servers))
+	primaryTarget := orderedTargets[0]
+	if target, _ := shr.GetTarget(key); target != primaryTarget {
+		t.Errorf("The target '%s' of key '%s' should be '%s'.", target, key, primaryTarget)
+		t.FailNow()
+	}
+	requestError := errors.New("i/o timeout")
+	shr.ReportResult(primaryTarget, requestError, time.Millisecond)
+	shr.ReportResult(primaryTarget, requestError, time.Millisecond)
+	if state := shr.GetBreakerState(primaryTarget); state != BREAKER_OPEN {
+		t.Errorf("The breaker of target '%s' should be open. (but %s)", primaryTarget, state)
+		t.FailNow()
+	}
+	if target, _ := shr.GetTarget(key); target != orderedTargets[1] {
+		t.Errorf("The target '%s' of key '%s' should fall through to '%s'.", target, key, orderedTargets[1])
+		t.FailNow()
+	}
+	if targets, _ := shr.GetTargets(key, 2); len(targets) != 2 || targets[0] != orderedTargets[1] || targets[1] != orderedTargets[2] {
+		t.Errorf("The targets %v of key '%s' should skip '%s'.", targets, key, primaryTarget)
+		t.FailNow()
+	}
+	if target, _ := shr.Snapshot().GetTarget(key); target != primaryTarget {
+		t.Errorf("The layout of ring should not be changed by the breaker.")
+		t.FailNow()
+	}
+	time.Sleep(100 * time.Millisecond)
+	if target, _ := shr.GetTarget(key); target != primaryTarget {
+		t.Errorf("The probe of key '%s' should be sent to '%s'. (but %s)", key, primaryTarget, target)
+		t.FailNow()
+	}
+	if target, _ := shr.GetTarget(key); target != orderedTargets[1] {
+		t.Errorf("Only one probe should be sent to '%s'. (but %s)", primaryTarget, target)
+		t.FailNow()
+	}
+	shr.ReportResult(primaryTarget, nil, time.Millisecond)
+	if target, _ := shr.GetTarget(key); target != primaryTarget || shr.GetBreakerState(primaryTarget) != BREAKER_CLOSED {
+		t.Errorf("The breaker of target '%s' should be closed. (state=%s)", primaryTarget, shr.GetBreakerState(primaryTarget))
+		t.FailNow()
+	}
+	for _, s := range servers {
+		shr.ReportResult(s, requestError, time.Millisecond)
+		shr.ReportResult(s, requestError, time.Millisecond)
+	}
+	if _, err := shr.GetTarget(key); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Getting target with all breakers open should be '%v'. (but %v)", ErrCircuitOpen, err)
+		t.FailNow()
+	}
+}
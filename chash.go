@@ -1,10 +1,12 @@
 package chash4go
 
 import (
-	"errors"
+	"context"
 	"fmt"
-	"go_lib"
-	"runtime/debug"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 type NodeCheckFunc func(target string) bool
@@ -19,6 +21,11 @@ const (
 	DESTROYED     HashRingStatus = "DESTROYED"
 )
 
+// The version of HashRing interface. It is increased when the interface is changed.
+// The version 2 adds the replica lookups & membership queries, and unifies
+// the results of adding & removing target.
+const HASH_RING_VERSION = 2
+
 type HashRing interface {
 	Build(shadowNumber uint16) error
 	Destroy() error
@@ -30,38 +37,122 @@ type HashRing interface {
 	AddTarget(target string) error
 	RemoveTarget(target string) error
 	GetTarget(key string) (string, error)
+	GetTargets(key string, number int) ([]string, error)
+	ContainsTarget(target string) bool
+	GetActiveTargets() []string
+	GetPendingTargets() []string
 }
 
+type KeyHashVersion uint8
+
+// Key hash version
+const (
+	// The legacy version which averages the ketama numbers of key.
+	KEY_HASH_V1 KeyHashVersion = 1
+	// The uniform version which takes the first ketama number of key.
+	KEY_HASH_V2 KeyHashVersion = 2
+)
+
+// The weight of target which has the standard shadow number.
+const DEFAULT_WEIGHT uint16 = 100
+
+// The hash ring based on ketama. The node hasher is for the placement of
+// nodes, and the key hasher is for the lookup of keys. Both of them are
+// SHA-1 by default. The key hash version is 'KEY_HASH_V1' by default to keep
+// the legacy layout, and 'KEY_HASH_V2' is opt-in. The compatibility profile
+// overrides the hashers & shadow numbers to reproduce other libraries.
 type SimpleHashRing struct {
+	Compatibility    CompatibilityProfile
+	NodeHasher       Hasher
+	KeyHasher        Hasher
+	KeyHashVersion   KeyHashVersion
 	nodeRing         *NodeRing
 	targetMap        map[string][]uint64
 	pendingTargetMap map[string][]uint64
-	changeSign       *go_lib.RWSign
+	weightMap        map[string]uint16
+	shadowCountMap   map[string]int
+	healthMap        map[string]*TargetHealth
+	stateMap         map[string]*targetStatus
+	checkTimeout     time.Duration
+	checkConcurrency int
+	riseThreshold    int
+	fallThreshold    int
+	flapDamping      *FlapDamping
+	panicThreshold   float64
+	inPanic          bool
+	eventListener    RingEventFunc
+	outlierDetection *OutlierDetection
+	outlierLock      sync.Mutex
+	outlierStatMap   map[string]*outlierStat
+	outlierTime      time.Time
+	outlierTimer     *time.Timer
+	circuitBreaker   *CircuitBreaker
+	breakerLock      sync.RWMutex
+	breakerMap       map[string]*targetBreaker
+	weightRamp       *WeightRamp
+	rampMap          map[string]*targetRamp
+	rampTimer        *time.Timer
+	checking         int32
+	changeLock       sync.RWMutex
+	snapshot         atomic.Value
+	loadBalancer     boundedLoadBalancer
 	shadowNumber     uint16
-	checker          Checker
 	status           HashRingStatus
+	ringLogger
+	ringChecker
 }
 
+var _ HashRing = (*SimpleHashRing)(nil)
+
 func (self *SimpleHashRing) initialize() {
 	self.nodeRing = NewNodeRing()
 	self.targetMap = make(map[string][]uint64, 0)
 	self.pendingTargetMap = make(map[string][]uint64, 0)
+	self.weightMap = make(map[string]uint16, 0)
+	self.shadowCountMap = make(map[string]int, 0)
+	self.healthMap = make(map[string]*TargetHealth)
+	self.stateMap = make(map[string]*targetStatus)
+	self.rampMap = make(map[string]*targetRamp)
+	self.inPanic = false
+	self.outlierLock.Lock()
+	self.outlierStatMap = nil
+	self.outlierLock.Unlock()
+	self.breakerLock.Lock()
+	self.breakerMap = nil
+	self.breakerLock.Unlock()
+	self.loadBalancer.reset()
+	if self.Compatibility == LIBKETAMA {
+		self.NodeHasher = MD5Hasher{}
+		self.KeyHasher = MD5Hasher{}
+		self.KeyHashVersion = KEY_HASH_V2
+	}
+	if self.NodeHasher == nil {
+		self.NodeHasher = SHA1Hasher{}
+	}
+	if self.KeyHasher == nil {
+		self.KeyHasher = SHA1Hasher{}
+	}
+	if self.KeyHashVersion == 0 {
+		self.KeyHashVersion = KEY_HASH_V1
+	}
 	self.shadowNumber = uint16(1000)
 	self.status = INITIALIZED
 }
 
-func (self *SimpleHashRing) Build(shadowNumber uint16) error {
-	self.getChangeSign().Set()
-	defer func() {
-		self.getChangeSign().Unset()
-		if err := recover(); err != nil {
-			errorMsg := fmt.Sprintf("Occur FATAL error when build hash ring: %s", err)
-			logger.Fatalln(errorMsg)
-			debug.PrintStack()
-		}
-	}()
+func (self *SimpleHashRing) Build(shadowNumber uint16) (err error) {
+	self.changeLock.Lock()
+	defer self.changeLock.Unlock()
+	defer recoverError("build hash ring", &err, self.getLogger())
 	switch self.status {
 	case "", UNINITIALIZED, DESTROYED:
+		for _, hasher := range []Hasher{self.NodeHasher, self.KeyHasher} {
+			if hasher == nil {
+				continue
+			}
+			if err := validateHasher(hasher); err != nil {
+				return err
+			}
+		}
 		self.initialize()
 		fallthrough
 	case INITIALIZED:
@@ -69,250 +160,378 @@ func (self *SimpleHashRing) Build(shadowNumber uint16) error {
 			self.shadowNumber = shadowNumber
 		}
 		self.status = BUILDED
+		self.publish()
 	default:
-		errorMsg := "Please destroy hash ring before rebuilding."
-		logger.Errorln(errorMsg)
-		return errors.New(errorMsg)
+		return ErrAlreadyBuilt
 	}
 	return nil
 }
 
-func (self *SimpleHashRing) Destroy() error {
-	self.getChangeSign().Set()
-	defer func() {
-		self.getChangeSign().Unset()
-		if err := recover(); err != nil {
-			errorMsg := fmt.Sprintf("Occur FATAL error when build hash ring: %s", err)
-			logger.Fatalln(errorMsg)
-			debug.PrintStack()
-		}
-	}()
+func (self *SimpleHashRing) Destroy() (err error) {
+	self.StopCheck()
+	self.changeLock.Lock()
+	defer self.changeLock.Unlock()
+	defer recoverError("destroy hash ring", &err, self.getLogger())
 	switch self.status {
 	case INITIALIZED, BUILDED:
 		self.nodeRing = nil
 		self.targetMap = nil
 		self.pendingTargetMap = nil
-		self.changeSign = nil
+		self.weightMap = nil
+		self.shadowCountMap = nil
+		self.healthMap = nil
+		self.stateMap = nil
+		self.rampMap = nil
+		if self.rampTimer != nil {
+			self.rampTimer.Stop()
+			self.rampTimer = nil
+		}
+		if self.outlierTimer != nil {
+			self.outlierTimer.Stop()
+			self.outlierTimer = nil
+		}
 		self.shadowNumber = uint16(0)
-		self.StopCheck()
 		self.status = DESTROYED
+		self.snapshot.Store((*RingSnapshot)(nil))
 	default:
-		warningMsg := "The hash ring were not builded. IGNORE the destroy operation."
-		logger.Warnln(warningMsg)
+		return ErrNotBuilt
 	}
 	return nil
 }
 
 func (self *SimpleHashRing) Status() HashRingStatus {
+	self.changeLock.RLock()
+	defer self.changeLock.RUnlock()
 	if len(self.status) == 0 {
-		self.status = UNINITIALIZED
+		return UNINITIALIZED
 	}
 	return self.status
 }
 
+// Check the targets by the node check function. The node check function takes
+// no context, so it has no timeout unless 'WithCheckTimeout' is set.
 func (self *SimpleHashRing) Check(nodeCheckFunc NodeCheckFunc) error {
-	defer func() {
-		if err := recover(); err != nil {
-			errorMsg := fmt.Sprintf("Occur FATAL error when check node ring: %s", err)
-			logger.Fatalln(errorMsg)
-			debug.PrintStack()
-		}
-	}()
-	for target, nodeKeys := range self.targetMap {
-		if !nodeCheckFunc(target) {
-			logger.Infof("Removing invalid target '%s'...", target)
-			if self.removeNodeByKeys(self.nodeRing, nodeKeys) {
-				self.pendingTargetMap[target] = nodeKeys
-				delete(self.targetMap, target)
-			}
-		}
+	return self.checkNodes(context.Background(), toHealthCheckFunc(nodeCheckFunc))
+}
+
+func (self *SimpleHashRing) StartCheck(nodeCheckFunc NodeCheckFunc, intervalSeconds uint16) (bool, error) {
+	return self.startHealthCheck(toHealthCheckFunc(nodeCheckFunc), intervalSeconds, self.checkNodes)
+}
+
+// Check the targets by the converted node check function, with the timeout
+// which is set by 'WithCheckTimeout' only.
+func (self *SimpleHashRing) checkNodes(ctx context.Context, healthCheckFunc HealthCheckFunc) error {
+	return self.healthCheck(ctx, healthCheckFunc, self.checkTimeout)
+}
+
+func (self *SimpleHashRing) AddTarget(target string) error {
+	return self.AddWeightedTarget(target, DEFAULT_WEIGHT)
+}
+
+func (self *SimpleHashRing) AddWeightedTarget(target string, weight uint16) (err error) {
+	self.changeLock.Lock()
+	defer self.changeLock.Unlock()
+	defer recoverError("add target", &err, self.getLogger())
+	if self.status != BUILDED {
+		return ErrNotBuilt
 	}
-	for target, nodeKeys := range self.pendingTargetMap {
-		if nodeCheckFunc(target) {
-			logger.Infof("Adding valid target '%s'...", target)
-			validNodeKeys, done := self.addNodesOfTarget(self.nodeRing, target, nodeKeys)
-			if done {
-				self.targetMap[target] = validNodeKeys
-				delete(self.pendingTargetMap, target)
-			}
-		}
+	if weight == 0 {
+		return &TargetError{"add target", target, ErrInvalidWeight}
+	}
+	if _, exists := self.weightMap[target]; exists {
+		return &TargetError{"add target", target, ErrTargetExists}
+	}
+	self.nodeRing = self.nodeRing.Clone()
+	if self.Compatibility == LIBKETAMA {
+		self.targetMap[target] = make([]uint64, 0)
+		self.weightMap[target] = weight
+		self.shadowCountMap[target] = 0
+		self.setTargetState(target, TARGET_ACTIVE, "", false, time.Now())
+		self.rebalanceShadows()
+		self.publish()
+		return nil
+	}
+	shadowCount := self.getShadowCount(weight)
+	now := time.Now()
+	if self.weightRamp != nil {
+		// The target starts with the initial percent of weight.
+		shadowCount = getRampShadowCount(shadowCount, self.weightRamp.InitialPercent)
+		self.rampMap[target] = &targetRamp{startTime: now, startPercent: self.weightRamp.InitialPercent}
+	}
+	nodeAll := self.getShadowNodes(target, 0, shadowCount)
+	validNodeKeys, done := self.addNodes(self.nodeRing, nodeAll...)
+	if !done {
+		return &TargetError{"add target", target, ErrKeyCollision}
+	}
+	if collisionNumber := len(nodeAll) - len(validNodeKeys); collisionNumber > 0 {
+		self.getLogger().Warnf("There are %d node keys of target '%s' in collision.", collisionNumber, target)
 	}
+	self.targetMap[target] = validNodeKeys
+	self.weightMap[target] = weight
+	self.shadowCountMap[target] = shadowCount
+	self.setTargetState(target, TARGET_ACTIVE, "", false, now)
+	self.scheduleRamps()
+	self.publish()
 	return nil
 }
 
-func (self *SimpleHashRing) StartCheck(nodeCheckFunc NodeCheckFunc, intervalSeconds uint16) (bool, error) {
-	defer func() {
-		if err := recover(); err != nil {
-			errorMsg := fmt.Sprintf("Occur FATAL error when start checker: %s", err)
-			logger.Fatalln(errorMsg)
-			debug.PrintStack()
-		}
-	}()
+// Change the weight of target. Only the shadows beyond the smaller
+// shadow count of old & new weight will be added or removed.
+func (self *SimpleHashRing) SetWeight(target string, weight uint16) (err error) {
+	self.changeLock.Lock()
+	defer self.changeLock.Unlock()
+	defer recoverError("set weight of target", &err, self.getLogger())
 	if self.status != BUILDED {
-		logger.Warnln("The hash ring were not builded. IGNORE the checker startup.")
-		return false, nil
+		return ErrNotBuilt
 	}
-	checkFunc := func() {
-		err := self.Check(nodeCheckFunc)
-		if err != nil {
-			logger.Errorf("Node ring checking is FAILING: %s\n", err)
-		}
+	if weight == 0 {
+		return &TargetError{"set weight", target, ErrInvalidWeight}
+	}
+	if _, exists := self.weightMap[target]; !exists {
+		return &TargetError{"set weight", target, ErrUnknownTarget}
 	}
-	if self.checker != nil && self.checker.InChecking() {
-		logger.Infoln("Stop checker before reinitialization.")
-		self.checker.Stop()
+	self.weightMap[target] = weight
+	self.nodeRing = self.nodeRing.Clone()
+	if self.Compatibility == LIBKETAMA {
+		self.rebalanceShadows()
+	} else {
+		self.resizeShadows(target, self.getRampedShadowCount(target, weight, time.Now()))
 	}
-	self.checker = NewChecker(intervalSeconds)
-	result := self.checker.Start(checkFunc)
-	return result, nil
+	self.publish()
+	return nil
 }
 
-func (self *SimpleHashRing) StopCheck() (bool, error) {
-	defer func() {
-		if err := recover(); err != nil {
-			errorMsg := fmt.Sprintf("Occur FATAL error when stop checker: %s", err)
-			logger.Fatalln(errorMsg)
-			debug.PrintStack()
-		}
-	}()
-	if self.checker == nil {
-		return false, nil
+// Get the configured weight of target which is in the ring. The weight is not
+// reduced while the target is ramping, see 'GetEffectiveWeight'.
+func (self *SimpleHashRing) GetWeight(target string) (uint16, bool) {
+	self.changeLock.RLock()
+	defer self.changeLock.RUnlock()
+	if _, active := self.targetMap[target]; !active {
+		return 0, false
 	}
-	result := self.checker.Stop()
-	return result, nil
+	weight, exists := self.weightMap[target]
+	return weight, exists
 }
 
-func (self *SimpleHashRing) InChecking() bool {
-	if self.checker == nil {
-		return false
+// Get the configured weights of all targets which are in the ring. The weights
+// are not reduced while the targets are ramping, see 'GetEffectiveWeights'.
+func (self *SimpleHashRing) GetWeights() map[string]uint16 {
+	self.changeLock.RLock()
+	defer self.changeLock.RUnlock()
+	weights := make(map[string]uint16, len(self.targetMap))
+	for target := range self.targetMap {
+		weights[target] = self.weightMap[target]
 	}
-	return self.checker.InChecking()
+	return weights
 }
 
-func (self *SimpleHashRing) AddTarget(target string) (bool, error) {
-	defer func() {
-		if err := recover(); err != nil {
-			errorMsg := fmt.Sprintf("Occur FATAL error when add target: %s", err)
-			logger.Fatalln(errorMsg)
-			debug.PrintStack()
-		}
-	}()
-	currentShadowNumber := int(self.shadowNumber)
-	targetShadows := make([]string, currentShadowNumber)
-	for i := 0; i < currentShadowNumber; i++ {
-		targetShadows[i] = fmt.Sprintf("%s-%d", target, i)
-	}
-	total := (currentShadowNumber * KETAMA_NUMBERS_LENGTH)
-	nodeAll := make([]Node, total)
-	nodeKeyAll := make([]uint64, total)
-	count := 0
-	for _, targetShadow := range targetShadows {
-		nodeKeys := GetKetamaNumbers(targetShadow)
-		for _, nodeKey := range nodeKeys {
-			nodeAll[count] = Node{nodeKey, target}
-			nodeKeyAll[count] = nodeKey
-			count++
-		}
+// Get the weight of target which is in effect. It is less than the configured
+// weight while the target is ramping.
+func (self *SimpleHashRing) GetEffectiveWeight(target string) (uint16, bool) {
+	self.changeLock.RLock()
+	defer self.changeLock.RUnlock()
+	if _, active := self.targetMap[target]; !active {
+		return 0, false
 	}
-	validNodeKeys, done := self.addNodes(self.nodeRing, nodeAll...)
-	if done {
-		self.targetMap[target] = validNodeKeys
+	return self.getEffectiveWeight(target), true
+}
+
+// Get the weights in effect of all targets which are in the ring.
+func (self *SimpleHashRing) GetEffectiveWeights() map[string]uint16 {
+	self.changeLock.RLock()
+	defer self.changeLock.RUnlock()
+	weights := make(map[string]uint16, len(self.targetMap))
+	for target := range self.targetMap {
+		weights[target] = self.getEffectiveWeight(target)
 	}
-	return done, nil
+	return weights
 }
 
-func (self *SimpleHashRing) RemoveTarget(target string) (bool, error) {
-	defer func() {
-		if err := recover(); err != nil {
-			errorMsg := fmt.Sprintf("Occur FATAL error when remove target: %s", err)
-			logger.Fatalln(errorMsg)
-			debug.PrintStack()
-		}
-	}()
-	nodeKeys := self.targetMap[target]
-	if nodeKeys == nil {
-		nodeKeys = self.pendingTargetMap[target]
+func (self *SimpleHashRing) RemoveTarget(target string) (err error) {
+	self.changeLock.Lock()
+	defer self.changeLock.Unlock()
+	defer recoverError("remove target", &err, self.getLogger())
+	if self.status != BUILDED {
+		return ErrNotBuilt
+	}
+	if _, exists := self.weightMap[target]; !exists {
+		return &TargetError{"remove target", target, ErrUnknownTarget}
 	}
-	if nodeKeys == nil || len(nodeKeys) == 0 {
-		return false, nil
+	self.nodeRing = self.nodeRing.Clone()
+	if nodeKeys, active := self.targetMap[target]; active {
+		self.removeNodeByKeys(self.nodeRing, nodeKeys)
 	}
-	self.removeNodeByKeys(self.nodeRing, nodeKeys)
 	delete(self.targetMap, target)
 	delete(self.pendingTargetMap, target)
-	return true, nil
+	delete(self.weightMap, target)
+	delete(self.shadowCountMap, target)
+	delete(self.healthMap, target)
+	delete(self.stateMap, target)
+	delete(self.rampMap, target)
+	self.outlierLock.Lock()
+	delete(self.outlierStatMap, target)
+	self.outlierLock.Unlock()
+	self.breakerLock.Lock()
+	delete(self.breakerMap, target)
+	self.breakerLock.Unlock()
+	self.rebalanceShadows()
+	self.publish()
+	return nil
+}
+
+func (self *SimpleHashRing) ContainsTarget(target string) bool {
+	self.changeLock.RLock()
+	defer self.changeLock.RUnlock()
+	_, exists := self.weightMap[target]
+	return exists
+}
+
+func (self *SimpleHashRing) GetActiveTargets() []string {
+	self.changeLock.RLock()
+	defer self.changeLock.RUnlock()
+	return self.getActiveTargets()
+}
+
+func (self *SimpleHashRing) GetPendingTargets() []string {
+	self.changeLock.RLock()
+	defer self.changeLock.RUnlock()
+	return self.getPendingTargets()
+}
+
+func (self *SimpleHashRing) getActiveTargets() []string {
+	targets := make([]string, 0, len(self.targetMap))
+	for target := range self.targetMap {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+	return targets
+}
+
+func (self *SimpleHashRing) getPendingTargets() []string {
+	targets := make([]string, 0, len(self.pendingTargetMap))
+	for target := range self.pendingTargetMap {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+	return targets
 }
 
+// Get the target of key. If the circuit breakers are enabled, the target whose
+// breaker is open is skipped, and the next target on the ring is returned.
 func (self *SimpleHashRing) GetTarget(key string) (string, error) {
-	self.getChangeSign().RSet()
-	defer func() {
-		self.getChangeSign().RUnset()
-		if err := recover(); err != nil {
-			errorMsg := fmt.Sprintf("Occur FATAL error when get target of key '%s': %s", key, err)
-			logger.Fatalln(errorMsg)
-			debug.PrintStack()
-		}
-	}()
-	results, err := self.GetTargets(key, 1)
-	if err != nil {
-		return "", err
+	if self.circuitBreaker == nil {
+		return self.Snapshot().GetTarget(key)
 	}
-	if len(results) == 0 {
-		return "", nil
+	results, err := self.getTargetsWithBreakers(key, 1)
+	if err != nil || len(results) == 0 {
+		return "", err
 	}
 	return results[0], nil
 }
 
 func (self *SimpleHashRing) GetTargets(key string, number int) ([]string, error) {
-	self.getChangeSign().RSet()
-	defer func() {
-		self.getChangeSign().RUnset()
-		if err := recover(); err != nil {
-			errorMsg := fmt.Sprintf("Occur FATAL error when get targets of key '%s' (number=%d): %s", key, number, err)
-			logger.Fatalln(errorMsg)
-			debug.PrintStack()
+	if self.circuitBreaker == nil {
+		return self.Snapshot().GetTargets(key, number)
+	}
+	return self.getTargetsWithBreakers(key, number)
+}
+
+// Get the current snapshot of ring. The lookups on it need no lock.
+// It is nil if the ring is not builded.
+func (self *SimpleHashRing) Snapshot() *RingSnapshot {
+	snapshot, _ := self.snapshot.Load().(*RingSnapshot)
+	return snapshot
+}
+
+func (self *SimpleHashRing) getShadowCount(weight uint16) int {
+	shadowCount := int(self.shadowNumber) * int(weight) / int(DEFAULT_WEIGHT)
+	if shadowCount < 1 {
+		shadowCount = 1
+	}
+	return shadowCount
+}
+
+// Add or remove the shadows of target which are beyond the smaller one of
+// the current & new shadow count.
+func (self *SimpleHashRing) resizeShadows(target string, shadowCount int) {
+	nodeKeys, active := self.targetMap[target]
+	if !active {
+		nodeKeys = self.pendingTargetMap[target]
+	}
+	oldShadowCount := self.shadowCountMap[target]
+	switch {
+	case shadowCount > oldShadowCount:
+		deltaNodes := self.getShadowNodes(target, oldShadowCount, shadowCount)
+		if active {
+			validNodeKeys, _ := self.addNodes(self.nodeRing, deltaNodes...)
+			nodeKeys = append(nodeKeys, validNodeKeys...)
+		} else {
+			for _, node := range deltaNodes {
+				nodeKeys = append(nodeKeys, node.Key)
+			}
 		}
-	}()
-	results := make([]string, 0)
-	if len(key) == 0 {
-		return results, nil
-	}
-	if number <= 0 {
-		number = 1
-	}
-	targetNumber := len(self.targetMap)
-	if number > targetNumber {
-		number = targetNumber
-	}
-	keyHash := GetHashForKey(key)
-	currentKeyHash := keyHash
-	for len(results) < number {
-		matchedNode := self.nodeRing.Next(currentKeyHash)
-		nodeHash := matchedNode.Key
-		target := matchedNode.Target
-		contain := false
-		for _, t := range results {
-			if t == target {
-				contain = true
-				break
+	case shadowCount < oldShadowCount:
+		deltaNodeKeySet := make(map[uint64]bool)
+		for _, node := range self.getShadowNodes(target, shadowCount, oldShadowCount) {
+			deltaNodeKeySet[node.Key] = true
+		}
+		retainedNodeKeys := make([]uint64, 0, len(nodeKeys))
+		removedNodeKeys := make([]uint64, 0, len(nodeKeys))
+		for _, nodeKey := range nodeKeys {
+			if deltaNodeKeySet[nodeKey] {
+				removedNodeKeys = append(removedNodeKeys, nodeKey)
+			} else {
+				retainedNodeKeys = append(retainedNodeKeys, nodeKey)
 			}
 		}
-		if !contain {
-			results = append(results, target)
+		if active {
+			self.removeNodeByKeys(self.nodeRing, removedNodeKeys)
+		}
+		nodeKeys = retainedNodeKeys
+	}
+	if active {
+		self.targetMap[target] = nodeKeys
+	} else {
+		self.pendingTargetMap[target] = nodeKeys
+	}
+	self.shadowCountMap[target] = shadowCount
+}
+
+// Generate the nodes of the shadows in [begin, end) of target.
+func (self *SimpleHashRing) getShadowNodes(target string, begin int, end int) []Node {
+	if end <= begin {
+		return nil
+	}
+	nodes := make([]Node, 0, (end-begin)*KETAMA_NUMBERS_LENGTH)
+	for i := begin; i < end; i++ {
+		targetShadow := fmt.Sprintf("%s-%d", target, i)
+		for _, nodeKey := range GetKetamaNumbersByHasher(self.NodeHasher, targetShadow) {
+			nodes = append(nodes, Node{nodeKey, target})
 		}
-		currentKeyHash = nodeHash + 1
 	}
-	return results, nil
+	return nodes
+}
+
+func (self *SimpleHashRing) getKeyHash(key string) uint64 {
+	return getKeyHashByVersion(self.KeyHasher, self.KeyHashVersion, key)
+}
+
+// Publish the node ring as the current snapshot. The node ring should be
+// copied before the next change since it is shared by the snapshot.
+func (self *SimpleHashRing) publish() {
+	self.snapshot.Store(&RingSnapshot{
+		nodeRing:       self.nodeRing,
+		targets:        self.getActiveTargets(),
+		keyHasher:      self.KeyHasher,
+		keyHashVersion: self.KeyHashVersion,
+	})
 }
 
 func (self *SimpleHashRing) addNodes(nodeRing *NodeRing, nodes ...Node) ([]uint64, bool) {
-	self.getChangeSign().Set()
-	defer self.getChangeSign().Unset()
 	return nodeRing.Add(nodes...)
 }
 
 func (self *SimpleHashRing) addNodesOfTarget(nodeRing *NodeRing, target string, nodeKeys []uint64) ([]uint64, bool) {
-	self.getChangeSign().Set()
-	defer self.getChangeSign().Unset()
 	nodes := make([]Node, len(nodeKeys))
 	for i, nodeKey := range nodeKeys {
 		nodes[i] = Node{nodeKey, target}
@@ -321,18 +540,9 @@ func (self *SimpleHashRing) addNodesOfTarget(nodeRing *NodeRing, target string,
 }
 
 func (self *SimpleHashRing) removeNodeByKeys(nodeRing *NodeRing, nodeKeys []uint64) bool {
-	self.getChangeSign().Set()
-	defer self.getChangeSign().Unset()
 	result := true
 	for _, nodeKey := range nodeKeys {
 		result = nodeRing.Remove(nodeKey) && result
 	}
 	return result
 }
-
-func (self *SimpleHashRing) getChangeSign() *go_lib.RWSign {
-	if self.changeSign == nil {
-		self.changeSign = go_lib.NewRWSign()
-	}
-	return self.changeSign
-}
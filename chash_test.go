@@ -1,8 +1,11 @@
 package chash4go
 
 import (
+	"errors"
 	"math/rand"
 	"strconv"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -27,7 +30,7 @@ func TestSimpleHashRing(t *testing.T) {
 	}
 	t.Logf("Add servers (%v)...", servers)
 	for _, s := range servers {
-		_, err := shr.AddTarget(s)
+		err := shr.AddTarget(s)
 		if err != nil {
 			t.Errorf("Adding server Error: %s", err)
 			t.FailNow()
@@ -90,13 +93,14 @@ func TestSimpleHashRing(t *testing.T) {
 		t.Errorf("The target '%s' of key '%s' should be '%s'.", target, key, expectedTarget)
 		t.FailNow()
 	}
-	done, err = shr.RemoveTarget(target)
+	err = shr.RemoveTarget(target)
 	if err != nil {
 		t.Errorf("Removing target '%s' Error: %s", target, err)
 		t.FailNow()
 	}
-	if !done {
-		t.Errorf("Removing target '%s' is FAILING.", target)
+	err = shr.RemoveTarget(target)
+	if err == nil {
+		t.Errorf("Removing target '%s' again should be FAILING.", target)
 		t.FailNow()
 	}
 	t.Logf("Removed target : %s", target)
@@ -139,7 +143,7 @@ func TestSimpleHashRingForBenchmark(t *testing.T) {
 	}
 	t.Logf("Add servers (%v)...", servers)
 	for _, s := range servers {
-		_, err := shr.AddTarget(s)
+		err := shr.AddTarget(s)
 		if err != nil {
 			t.Errorf("Adding server Error: %s", err)
 			t.FailNow()
@@ -179,3 +183,260 @@ func TestSimpleHashRingForBenchmark(t *testing.T) {
 	}
 	t.Logf("The benchmak of hash ring is end.")
 }
+
+func TestSimpleHashRingWithWeight(t *testing.T) {
+	shr := SimpleHashRing{}
+	err := shr.Build(100)
+	if err != nil {
+		t.Errorf("Build hash ring Error: %s", err)
+		t.FailNow()
+	}
+	weights := map[string]uint16{"10.11.5.145:2181": 100, "10.11.5.164:2181": 300}
+	for target, weight := range weights {
+		err := shr.AddWeightedTarget(target, weight)
+		if err != nil {
+			t.Errorf("Adding weighted target '%s' is FAILING. (err=%v)", target, err)
+			t.FailNow()
+		}
+	}
+	if err := shr.AddWeightedTarget("192.168.106.63:2181", 0); err == nil {
+		t.Errorf("Adding target with zero weight should be FAILING.")
+		t.FailNow()
+	}
+	for target, weight := range weights {
+		effectiveWeight, ok := shr.GetWeight(target)
+		if !ok || effectiveWeight != weight {
+			t.Errorf("The weight '%v' of target '%s' should be '%v'.", effectiveWeight, target, weight)
+			t.FailNow()
+		}
+	}
+	keyNumber := 20000
+	keys := make([]string, keyNumber)
+	for i := 0; i < keyNumber; i++ {
+		keys[i] = "key-" + strconv.Itoa(i)
+	}
+	getTargetsOfKeys := func() map[string]string {
+		result := make(map[string]string, keyNumber)
+		for _, key := range keys {
+			target, err := shr.GetTarget(key)
+			if err != nil {
+				t.Errorf("Getting target Error: %s", err)
+				t.FailNow()
+			}
+			result[key] = target
+		}
+		return result
+	}
+	before := getTargetsOfKeys()
+	counts := make(map[string]int)
+	for _, target := range before {
+		counts[target]++
+	}
+	ratio := float64(counts["10.11.5.164:2181"]) / float64(counts["10.11.5.145:2181"])
+	t.Logf("The key counts: %v (ratio=%f)", counts, ratio)
+	if ratio < 2 || ratio > 4 {
+		t.Errorf("The ratio '%f' of key counts should be near to 3.", ratio)
+		t.FailNow()
+	}
+	// begin - test about growing weight
+	err = shr.SetWeight("10.11.5.145:2181", 200)
+	if err != nil {
+		t.Errorf("Setting weight is FAILING. (err=%v)", err)
+		t.FailNow()
+	}
+	after := getTargetsOfKeys()
+	for key, target := range after {
+		if target != before[key] && target != "10.11.5.145:2181" {
+			t.Errorf("The key '%s' should not move from '%s' to '%s'.", key, before[key], target)
+			t.FailNow()
+		}
+	}
+	// end - test about growing weight
+	// begin - test about shrinking weight
+	err = shr.SetWeight("10.11.5.145:2181", 100)
+	if err != nil {
+		t.Errorf("Setting weight is FAILING. (err=%v)", err)
+		t.FailNow()
+	}
+	restored := getTargetsOfKeys()
+	for key, target := range restored {
+		if target != before[key] {
+			t.Errorf("The target '%s' of key '%s' should be restored to '%s'.", target, key, before[key])
+			t.FailNow()
+		}
+	}
+	// end - test about shrinking weight
+	if len(shr.GetWeights()) != len(weights) {
+		t.Errorf("The length of weights %v should be %v.", shr.GetWeights(), len(weights))
+		t.FailNow()
+	}
+}
+
+func TestSimpleHashRingWithUniformKeyHash(t *testing.T) {
+	servers := [...]string{"10.11.156.71:2181", "10.11.5.145:2181", "10.11.5.164:2181", "192.168.106.63:2181", "192.168.106.64:2181"}
+	shr := SimpleHashRing{KeyHashVersion: KEY_HASH_V2}
+	shr.Build(500)
+	for _, s := range servers {
+		shr.AddTarget(s)
+	}
+	key := "chash_test"
+	expectedTargets := []string{"10.11.5.164:2181", "192.168.106.64:2181"}
+	for _, expectedTarget := range expectedTargets {
+		target, err := shr.GetTarget(key)
+		if err != nil {
+			t.Errorf("Getting target Error: %s", err)
+			t.FailNow()
+		}
+		if target != expectedTarget {
+			t.Errorf("The target '%s' of key '%s' should be '%s' in uniform layout.", target, key, expectedTarget)
+			t.FailNow()
+		}
+		shr.RemoveTarget(target)
+	}
+}
+
+func TestSimpleHashRingForDistribution(t *testing.T) {
+	servers := [...]string{"10.11.156.71:2181", "10.11.5.145:2181", "10.11.5.164:2181", "192.168.106.63:2181", "192.168.106.64:2181"}
+	shr := SimpleHashRing{KeyHashVersion: KEY_HASH_V2}
+	shr.Build(500)
+	for _, s := range servers {
+		shr.AddTarget(s)
+	}
+	// The expected share of each target is the proportion of node space it owns.
+	nodeKeys := shr.nodeRing.GetAllNodeKey()
+	expectedShares := make(map[string]float64)
+	previousKey := nodeKeys[len(nodeKeys)-1]
+	for _, nodeKey := range nodeKeys {
+		arc := float64(uint32(nodeKey) - uint32(previousKey))
+		expectedShares[shr.nodeRing.Get(nodeKey).Target] += arc / float64(uint64(1)<<32)
+		previousKey = nodeKey
+	}
+	keyNumber := 200000
+	counts := make(map[string]int)
+	for i := 0; i < keyNumber; i++ {
+		target, _ := shr.GetTarget("key-" + strconv.Itoa(i))
+		counts[target]++
+	}
+	tolerance := 0.05
+	for _, s := range servers {
+		share := float64(counts[s]) / float64(keyNumber)
+		deviation := share/expectedShares[s] - 1
+		t.Logf("The share of target '%s': %f (expected=%f, deviation=%f)", s, share, expectedShares[s], deviation)
+		if deviation > tolerance || deviation < -tolerance {
+			t.Errorf("The deviation '%f' of share of target '%s' should be within %f.", deviation, s, tolerance)
+			t.FailNow()
+		}
+	}
+}
+
+// It should be run with '-race'.
+func TestHashRingForConcurrency(t *testing.T) {
+	servers := [...]string{"10.11.156.71:2181", "10.11.5.145:2181", "10.11.5.164:2181", "192.168.106.63:2181", "192.168.106.64:2181"}
+	extraServers := [...]string{"10.11.156.72:2181", "10.11.5.146:2181", "10.11.5.165:2181"}
+	hashRings := []HashRing{&SimpleHashRing{}, &JumpHashRing{}, &RendezvousRing{}, &MaglevRing{}, &MultiProbeRing{}}
+	for _, hashRing := range hashRings {
+		hashRing.Build(100)
+		for _, s := range servers {
+			hashRing.AddTarget(s)
+		}
+		// The first server is flapping, and the others are always valid.
+		var flapCount int64
+		nodeCheckFunc := func(target string) bool {
+			if target != servers[0] {
+				return true
+			}
+			return atomic.AddInt64(&flapCount, 1)%2 == 0
+		}
+		if _, err := hashRing.StartCheck(nodeCheckFunc, uint16(1)); err != nil {
+			t.Errorf("Starting check of %T Error: %s", hashRing, err)
+			t.FailNow()
+		}
+		deadline := time.Now().Add(1200 * time.Millisecond)
+		var wg sync.WaitGroup
+		for i := 0; i < 2; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				for j := i; time.Now().Before(deadline); j++ {
+					target := extraServers[j%len(extraServers)]
+					err := hashRing.AddTarget(target)
+					if err != nil && !errors.Is(err, ErrTargetExists) {
+						t.Errorf("Adding target '%s' to %T Error: %s", target, hashRing, err)
+						return
+					}
+					err = hashRing.RemoveTarget(target)
+					if err != nil && !errors.Is(err, ErrUnknownTarget) {
+						t.Errorf("Removing target '%s' from %T Error: %s", target, hashRing, err)
+						return
+					}
+				}
+			}(i)
+		}
+		for i := 0; i < 2; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for time.Now().Before(deadline) {
+					err := hashRing.Check(nodeCheckFunc)
+					if err != nil && !errors.Is(err, ErrCheckInProgress) {
+						t.Errorf("Checking %T Error: %s", hashRing, err)
+						return
+					}
+				}
+			}()
+		}
+		// The checker is restarted & stopped concurrently.
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for time.Now().Before(deadline) {
+					if _, err := hashRing.StartCheck(nodeCheckFunc, uint16(1)); err != nil {
+						t.Errorf("Starting check of %T Error: %s", hashRing, err)
+						return
+					}
+					hashRing.InChecking()
+					if _, err := hashRing.StopCheck(); err != nil {
+						t.Errorf("Stopping check of %T Error: %s", hashRing, err)
+						return
+					}
+					time.Sleep(10 * time.Millisecond)
+				}
+			}()
+		}
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				for j := 0; time.Now().Before(deadline); j++ {
+					key := "key-" + strconv.Itoa(i) + "-" + strconv.Itoa(j)
+					target, err := hashRing.GetTarget(key)
+					if err != nil || len(target) == 0 {
+						t.Errorf("The target '%s' of key '%s' in %T is unexpected. (err=%v)", target, key, hashRing, err)
+						return
+					}
+					targets, err := hashRing.GetTargets(key, 2)
+					if err != nil || len(targets) != 2 {
+						t.Errorf("The targets %v of key '%s' in %T are unexpected. (err=%v)", targets, key, hashRing, err)
+						return
+					}
+					hashRing.ContainsTarget(target)
+					hashRing.GetActiveTargets()
+					hashRing.GetPendingTargets()
+				}
+			}(i)
+		}
+		wg.Wait()
+		hashRing.StopCheck()
+		for _, s := range servers {
+			if !hashRing.ContainsTarget(s) {
+				t.Errorf("The %T should contain the target '%s'.", hashRing, s)
+				t.FailNow()
+			}
+		}
+		if err := hashRing.Destroy(); err != nil {
+			t.Errorf("Destroying %T Error: %s", hashRing, err)
+			t.FailNow()
+		}
+	}
+}
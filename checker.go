@@ -1,6 +1,10 @@
 package chash4go
 
 import (
+	"context"
+	"math/rand"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -12,54 +16,242 @@ type Checker interface {
 	InChecking() bool
 }
 
+// The default interval of cycle checker.
+const DEFAULT_CHECK_INTERVAL = 2 * time.Second
+
+/*
+ * The checker which calls the check function in cycles. The interval is
+ * 'Interval', or 'IntervalSeconds' if it is zero. A random duration in
+ * [0, Jitter) is added to each interval to spread the checks of different
+ * processes.
+ */
 type CycleChecker struct {
 	IntervalSeconds uint16
+	Interval        time.Duration
+	Jitter          time.Duration
+	Logger          Logger
+	lock            sync.Mutex
 	checkingTag     bool
-	stopSign        chan bool
+	stopSign        chan struct{}
+	doneSign        chan struct{}
+	checkSign       chan struct{}
 	count           uint64
 }
 
 func (self *CycleChecker) Start(checkFunc CheckFunc) bool {
+	self.lock.Lock()
+	defer self.lock.Unlock()
 	if self.checkingTag {
-		logger.Warnln("Please stop before restart.")
+		self.getLogger().Warnf("Please stop before restart.")
 		return false
 	}
-	if self.IntervalSeconds <= 0 {
-		self.IntervalSeconds = 2
-	}
-	self.stopSign = make(chan bool, 1)
-	self.count = 0
-	tick := time.Tick(time.Duration(self.IntervalSeconds) * time.Second)
+	interval := self.getInterval()
+	jitter := self.Jitter
+	stopSign := make(chan struct{})
+	doneSign := make(chan struct{})
+	checkSign := make(chan struct{}, 1)
+	self.stopSign, self.doneSign, self.checkSign = stopSign, doneSign, checkSign
+	atomic.StoreUint64(&self.count, 0)
 	go func() {
+		defer close(doneSign)
+		timer := time.NewTimer(nextInterval(interval, jitter))
+		defer timer.Stop()
+		var count uint64
 		for {
 			select {
-			case <-tick:
-				checkFunc()
-				self.count++
-			case <-self.stopSign:
-				logger.Infof("The checker will be stop. (count=%d)", self.count)
-				break
+			case <-timer.C:
+				timer.Reset(nextInterval(interval, jitter))
+			case <-checkSign:
+			case <-stopSign:
+				self.getLogger().Infof("The checker will be stop. (count=%d)", count)
+				return
 			}
+			checkFunc()
+			count++
+			atomic.StoreUint64(&self.count, count)
 		}
 	}()
 	self.checkingTag = true
 	return true
 }
 
+// Stop the checker and wait for the running check to finish. It should not be
+// called in the check function.
 func (self *CycleChecker) Stop() bool {
+	self.lock.Lock()
 	if !self.checkingTag {
-		logger.Warnln("The checker were not started.")
+		self.lock.Unlock()
+		self.getLogger().Warnf("The checker were not started.")
 		return false
 	}
 	self.checkingTag = false
-	self.stopSign <- true
+	close(self.stopSign)
+	doneSign := self.doneSign
+	self.lock.Unlock()
+	<-doneSign
+	return true
+}
+
+// Trigger a check immediately. The trigger is merged into the pending one if
+// the check is running.
+func (self *CycleChecker) CheckNow() bool {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	if !self.checkingTag {
+		return false
+	}
+	select {
+	case self.checkSign <- struct{}{}:
+	default:
+	}
 	return true
 }
 
 func (self *CycleChecker) InChecking() bool {
+	self.lock.Lock()
+	defer self.lock.Unlock()
 	return self.checkingTag
 }
 
+// Get the number of checks since the checker is started.
+func (self *CycleChecker) GetCount() uint64 {
+	return atomic.LoadUint64(&self.count)
+}
+
+func (self *CycleChecker) getInterval() time.Duration {
+	if self.Interval > 0 {
+		return self.Interval
+	}
+	if self.IntervalSeconds > 0 {
+		return time.Duration(self.IntervalSeconds) * time.Second
+	}
+	return DEFAULT_CHECK_INTERVAL
+}
+
+func (self *CycleChecker) getLogger() Logger {
+	if self.Logger == nil {
+		return logger
+	}
+	return self.Logger
+}
+
+func nextInterval(interval time.Duration, jitter time.Duration) time.Duration {
+	if jitter <= 0 {
+		return interval
+	}
+	return interval + time.Duration(rand.Int63n(int64(jitter)))
+}
+
 func NewChecker(intervalSeconds uint16) Checker {
 	return interface{}(&CycleChecker{IntervalSeconds: intervalSeconds}).(Checker)
 }
+
+// Create a cycle checker with the interval and the jitter.
+func NewCycleChecker(interval time.Duration, jitter time.Duration) *CycleChecker {
+	return &CycleChecker{Interval: interval, Jitter: jitter}
+}
+
+// The checker holder which is shared by the hash rings.
+// The custom checker is used instead of the cycle checker if it is set.
+// The holder is guarded by its own lock, which is not held while the
+// checker is stopping.
+type ringChecker struct {
+	checkerLock   sync.Mutex
+	checker       Checker
+	customChecker Checker
+	cancelCheck   context.CancelFunc
+	checkLogger   Logger
+	checkInterval time.Duration
+	checkJitter   time.Duration
+}
+
+func (self *ringChecker) startChecker(checkFunc CheckFunc, intervalSeconds uint16, logger Logger) bool {
+	return self.startContextChecker(func(ctx context.Context) CheckFunc { return checkFunc }, intervalSeconds, logger)
+}
+
+// Start the checker with the check function which is made with the context
+// of the checks. The context is canceled by 'StopCheck'. The previous checker
+// is stopped before, and nothing is started if another one is started meanwhile.
+func (self *ringChecker) startContextChecker(newCheckFunc func(ctx context.Context) CheckFunc, intervalSeconds uint16, logger Logger) bool {
+	if self.InChecking() {
+		logger.Infof("Stop checker before reinitialization.")
+	}
+	self.stopChecker()
+	self.checkerLock.Lock()
+	defer self.checkerLock.Unlock()
+	if self.checker != nil && self.checker.InChecking() {
+		logger.Warnf("The checker is started by another caller.")
+		return false
+	}
+	checker := self.customChecker
+	if checker == nil {
+		checker = &CycleChecker{
+			IntervalSeconds: intervalSeconds,
+			Interval:        self.checkInterval,
+			Jitter:          self.checkJitter,
+			Logger:          logger,
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	if !checker.Start(newCheckFunc(ctx)) {
+		cancel()
+		return false
+	}
+	self.checker = checker
+	self.cancelCheck = cancel
+	self.checkLogger = logger
+	return true
+}
+
+// Cancel the running checks and stop the checker without holding the lock.
+func (self *ringChecker) stopChecker() bool {
+	self.checkerLock.Lock()
+	checker, cancel := self.checker, self.cancelCheck
+	self.cancelCheck = nil
+	self.checkerLock.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+	if checker == nil || !checker.InChecking() {
+		return false
+	}
+	return checker.Stop()
+}
+
+func (self *ringChecker) StopCheck() (done bool, err error) {
+	self.checkerLock.Lock()
+	started, checkLogger := self.checker != nil, self.checkLogger
+	self.checkerLock.Unlock()
+	if !started {
+		return false, nil
+	}
+	// The panic is logged by the logger of ring which started the checker.
+	defer recoverError("stop checker", &err, checkLogger)
+	return self.stopChecker(), nil
+}
+
+func (self *ringChecker) getChecker() Checker {
+	self.checkerLock.Lock()
+	defer self.checkerLock.Unlock()
+	return self.checker
+}
+
+// Trigger a check immediately if the checker supports it.
+func (self *ringChecker) CheckNow() bool {
+	checker := self.getChecker()
+	if checker == nil || !checker.InChecking() {
+		return false
+	}
+	if checker, ok := checker.(interface{ CheckNow() bool }); ok {
+		return checker.CheckNow()
+	}
+	return false
+}
+
+func (self *ringChecker) InChecking() bool {
+	checker := self.getChecker()
+	if checker == nil {
+		return false
+	}
+	return checker.InChecking()
+}
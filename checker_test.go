@@ -1,19 +1,18 @@
 package chash4go
 
 import (
+	"errors"
+	"sync/atomic"
 	"testing"
 	"time"
 )
 
 func TestCycleChecker(t *testing.T) {
-	intervalSeconds := 1
-	t.Logf("The intervalSeconds is %v", intervalSeconds)
-	checker := CycleChecker{IntervalSeconds: uint16(intervalSeconds)}
-	count := 0
-	t.Logf("The count is %v", count)
-	countChan := make(chan int, 1)
-	t.Logf("Start the checker...")
-	result := checker.Start(func() { countChan <- 1 })
+	interval := 50 * time.Millisecond
+	t.Logf("The interval is %v", interval)
+	checker := CycleChecker{Interval: interval}
+	var count int32
+	result := checker.Start(func() { atomic.AddInt32(&count, 1) })
 	if !result {
 		t.Errorf("The result is starting checker is FALSE! ")
 		t.FailNow()
@@ -22,38 +21,127 @@ func TestCycleChecker(t *testing.T) {
 		t.Errorf("The Checker is not successful running! ")
 		t.FailNow()
 	}
-	t.Logf("The checker is started.")
-	timeoutSeconds := intervalSeconds * 3
-	t.Logf("The timeoutSeconds is %v", timeoutSeconds)
-	timeoutChan := time.After(time.Duration(timeoutSeconds) * time.Second)
-	continueSign := make(chan bool)
-	go func() {
-		for {
-			select {
-			case i := <-countChan:
-				count += i
-				t.Logf("The count is %v.", count)
-			case <-timeoutChan:
-				t.Logf("Stop the checker...")
-				result := checker.Stop()
-				if !result {
-					t.Errorf("The result is stopping checker is FALSE! ")
-					t.FailNow()
-				}
-				continueSign <- true
-				break
-			}
-		}
-	}()
-	<-continueSign
-	t.Logf("The checker is stopped.")
+	if checker.Start(func() {}) {
+		t.Errorf("The running checker should not be restarted! ")
+		t.FailNow()
+	}
+	time.Sleep(interval*5 + interval/2)
+	if !checker.Stop() {
+		t.Errorf("The result is stopping checker is FALSE! ")
+		t.FailNow()
+	}
 	if checker.InChecking() {
 		t.Errorf("The Checker is still running! ")
 		t.FailNow()
 	}
-	if count != timeoutSeconds {
-		t.Errorf("The count '%v' should equals timeoutSeconds '%v'. ", count, timeoutSeconds)
+	stoppedCount := atomic.LoadInt32(&count)
+	if stoppedCount < 3 || stoppedCount > 6 {
+		t.Errorf("The count '%v' should be about 5. ", stoppedCount)
+		t.FailNow()
+	}
+	if checker.GetCount() != uint64(stoppedCount) {
+		t.Errorf("The count '%v' of checker should be '%v'. ", checker.GetCount(), stoppedCount)
+		t.FailNow()
+	}
+	time.Sleep(interval * 3)
+	if atomic.LoadInt32(&count) != stoppedCount {
+		t.Errorf("The stopped checker should not check any more. (count=%v)", atomic.LoadInt32(&count))
+		t.FailNow()
+	}
+	if checker.Stop() {
+		t.Errorf("The stopped checker should not be stopped again! ")
+		t.FailNow()
+	}
+	t.Logf("The count is %v. It's OK.", stoppedCount)
+}
+
+func TestCycleCheckerForStopWaiting(t *testing.T) {
+	checker := NewCycleChecker(10*time.Millisecond, 0)
+	var running, finished int32
+	checker.Start(func() {
+		atomic.StoreInt32(&running, 1)
+		time.Sleep(100 * time.Millisecond)
+		atomic.StoreInt32(&finished, 1)
+	})
+	for atomic.LoadInt32(&running) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	checker.Stop()
+	if atomic.LoadInt32(&finished) == 0 {
+		t.Errorf("The running check should be finished after stopping checker.")
+		t.FailNow()
+	}
+}
+
+func TestCycleCheckerForCheckNow(t *testing.T) {
+	checker := NewCycleChecker(time.Hour, time.Minute)
+	if checker.CheckNow() {
+		t.Errorf("The unstarted checker should not check.")
+		t.FailNow()
+	}
+	checkChan := make(chan bool, 1)
+	checker.Start(func() { checkChan <- true })
+	defer checker.Stop()
+	if !checker.CheckNow() {
+		t.Errorf("The result of checking now is FALSE! ")
+		t.FailNow()
+	}
+	select {
+	case <-checkChan:
+	case <-time.After(time.Second):
+		t.Errorf("The check is not triggered.")
+		t.FailNow()
+	}
+}
+
+func TestNextInterval(t *testing.T) {
+	interval, jitter := time.Second, 100*time.Millisecond
+	if next := nextInterval(interval, 0); next != interval {
+		t.Errorf("The next interval '%v' without jitter should be '%v'.", next, interval)
+		t.FailNow()
+	}
+	varied := false
+	first := nextInterval(interval, jitter)
+	for i := 0; i < 100; i++ {
+		next := nextInterval(interval, jitter)
+		if next < interval || next >= interval+jitter {
+			t.Errorf("The next interval '%v' should be in [%v, %v).", next, interval, interval+jitter)
+			t.FailNow()
+		}
+		if next != first {
+			varied = true
+		}
+	}
+	if !varied {
+		t.Errorf("The next intervals should be varied by the jitter.")
+		t.FailNow()
+	}
+}
+
+func TestSimpleHashRingWithCheckInterval(t *testing.T) {
+	shr, err := NewSimpleHashRing(WithShadowNumber(10), WithCheckInterval(20*time.Millisecond, 5*time.Millisecond))
+	if err != nil {
+		t.Errorf("Creating hash ring Error: %s", err)
+		t.FailNow()
+	}
+	shr.AddTarget("10.11.5.145:2181")
+	var count int32
+	shr.StartCheck(func(target string) bool {
+		atomic.AddInt32(&count, 1)
+		return true
+	}, uint16(10))
+	time.Sleep(200 * time.Millisecond)
+	shr.StopCheck()
+	if atomic.LoadInt32(&count) < 3 {
+		t.Errorf("The sub-second interval should be used. (count=%d)", atomic.LoadInt32(&count))
+		t.FailNow()
+	}
+	if shr.CheckNow() {
+		t.Errorf("The stopped checker should not check.")
+		t.FailNow()
+	}
+	if _, err := NewSimpleHashRing(WithCheckInterval(0, 0)); !errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("Creating hash ring with zero interval should be '%v'. (but %v)", ErrInvalidArgument, err)
 		t.FailNow()
 	}
-	t.Logf("The count is %v. It's OK.", count)
 }
@@ -0,0 +1,75 @@
+package chash4go
+
+import (
+	"math"
+	"time"
+)
+
+// The defaults of flap damping which are the same as the ones of BGP route flap damping.
+const (
+	DEFAULT_FLAP_PENALTY   float64       = 1000
+	DEFAULT_SUPPRESS_LIMIT float64       = 2000
+	DEFAULT_REUSE_LIMIT    float64       = 750
+	DEFAULT_HALF_LIFE      time.Duration = 15 * time.Minute
+)
+
+/*
+ * The flap damping of targets (like the BGP route flap damping).
+ * Each ejection of target adds the penalty which decays exponentially
+ * by the half-life. The target whose penalty exceeds the suppress limit
+ * is kept ejected until its penalty falls below the reuse limit.
+ * The penalty is not greater than the max penalty if it is set.
+ */
+type FlapDamping struct {
+	Penalty       float64
+	SuppressLimit float64
+	ReuseLimit    float64
+	HalfLife      time.Duration
+	MaxPenalty    float64
+}
+
+// Fill the zero fields with the defaults, and validate the damping.
+func (self *FlapDamping) normalize() error {
+	if self.Penalty == 0 {
+		self.Penalty = DEFAULT_FLAP_PENALTY
+	}
+	if self.SuppressLimit == 0 {
+		self.SuppressLimit = DEFAULT_SUPPRESS_LIMIT
+	}
+	if self.ReuseLimit == 0 {
+		self.ReuseLimit = DEFAULT_REUSE_LIMIT
+	}
+	if self.HalfLife == 0 {
+		self.HalfLife = DEFAULT_HALF_LIFE
+	}
+	switch {
+	case self.Penalty < 0:
+		return &ArgumentError{"Penalty", self.Penalty, "It should be greater than 0."}
+	case self.HalfLife < 0:
+		return &ArgumentError{"HalfLife", self.HalfLife, "It should be greater than 0."}
+	case self.ReuseLimit < 0 || self.ReuseLimit >= self.SuppressLimit:
+		return &ArgumentError{"ReuseLimit", self.ReuseLimit, "It should be less than the suppress limit."}
+	case self.MaxPenalty != 0 && self.MaxPenalty < self.SuppressLimit:
+		return &ArgumentError{"MaxPenalty", self.MaxPenalty, "It should not be less than the suppress limit."}
+	}
+	return nil
+}
+
+// Decay the penalty of target to the time, add the penalty into it, and then
+// update the suppression by the limits.
+func (self *FlapDamping) update(health *TargetHealth, now time.Time, penalty float64) {
+	if !health.penaltyTime.IsZero() {
+		elapsed := now.Sub(health.penaltyTime)
+		health.Penalty *= math.Exp2(-float64(elapsed) / float64(self.HalfLife))
+	}
+	health.penaltyTime = now
+	health.Penalty += penalty
+	if self.MaxPenalty > 0 && health.Penalty > self.MaxPenalty {
+		health.Penalty = self.MaxPenalty
+	}
+	if health.Penalty >= self.SuppressLimit {
+		health.Suppressed = true
+	} else if health.Penalty < self.ReuseLimit {
+		health.Suppressed = false
+	}
+}
@@ -0,0 +1,85 @@
+package chash4go
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestFlapDamping(t *testing.T) {
+	damping := FlapDamping{HalfLife: time.Minute}
+	if err := damping.normalize(); err != nil {
+		t.Errorf("Normalizing flap damping Error: %s", err)
+		t.FailNow()
+	}
+	health := &TargetHealth{}
+	now := time.Now()
+	damping.update(health, now, damping.Penalty)
+	if health.Penalty != DEFAULT_FLAP_PENALTY || health.Suppressed {
+		t.Errorf("The damping state (penalty=%f, suppressed=%v) is unexpected.", health.Penalty, health.Suppressed)
+		t.FailNow()
+	}
+	now = now.Add(time.Minute)
+	damping.update(health, now, damping.Penalty)
+	if math.Abs(health.Penalty-1500) > 1e-6 || health.Suppressed {
+		t.Errorf("The damping state (penalty=%f, suppressed=%v) is unexpected.", health.Penalty, health.Suppressed)
+		t.FailNow()
+	}
+	damping.update(health, now, damping.Penalty)
+	if !health.Suppressed {
+		t.Errorf("The target should be suppressed. (penalty=%f)", health.Penalty)
+		t.FailNow()
+	}
+	now = now.Add(time.Minute)
+	damping.update(health, now, 0)
+	if math.Abs(health.Penalty-1250) > 1e-6 || !health.Suppressed {
+		t.Errorf("The target should be still suppressed. (penalty=%f)", health.Penalty)
+		t.FailNow()
+	}
+	now = now.Add(time.Minute)
+	damping.update(health, now, 0)
+	if health.Suppressed {
+		t.Errorf("The target should be reused. (penalty=%f)", health.Penalty)
+		t.FailNow()
+	}
+	invalidDamping := FlapDamping{SuppressLimit: 500}
+	if err := invalidDamping.normalize(); !errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("The damping with reuse limit above suppress limit should be '%v'. (but %v)", ErrInvalidArgument, err)
+		t.FailNow()
+	}
+}
+
+func TestSimpleHashRingWithFlapDamping(t *testing.T) {
+	halfLife := 100 * time.Millisecond
+	shr, err := NewSimpleHashRing(WithShadowNumber(10), WithFlapDamping(FlapDamping{SuppressLimit: 1500, ReuseLimit: 600, HalfLife: halfLife}))
+	if err != nil {
+		t.Errorf("Creating hash ring Error: %s", err)
+		t.FailNow()
+	}
+	servers := [...]string{"10.11.5.145:2181", "10.11.5.164:2181"}
+	for _, s := range servers {
+		shr.AddTarget(s)
+	}
+	target := servers[0]
+	valid := true
+	nodeCheckFunc := func(server string) bool {
+		return server != target || valid
+	}
+	for _, v := range []bool{false, true, false, true} {
+		valid = v
+		shr.Check(nodeCheckFunc)
+	}
+	health, _ := shr.GetTargetHealth(target)
+	if _, active := shr.GetWeight(target); active || !health.Suppressed {
+		t.Errorf("The flapping target '%s' should be suppressed. (health=%+v)", target, health)
+		t.FailNow()
+	}
+	time.Sleep(2 * halfLife)
+	shr.Check(nodeCheckFunc)
+	health, _ = shr.GetTargetHealth(target)
+	if _, active := shr.GetWeight(target); !active || health.Suppressed {
+		t.Errorf("The target '%s' should be reused. (health=%+v)", target, health)
+		t.FailNow()
+	}
+}
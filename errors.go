@@ -0,0 +1,78 @@
+package chash4go
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Errors of hash ring
+var (
+	ErrNotBuilt        = errors.New("The hash ring is not builded.")
+	ErrAlreadyBuilt    = errors.New("The hash ring has been builded. Please destroy it before rebuilding.")
+	ErrEmptyRing       = errors.New("There is no valid target in the hash ring.")
+	ErrTargetExists    = errors.New("The target has been added.")
+	ErrUnknownTarget   = errors.New("The target is nonexistent.")
+	ErrKeyCollision    = errors.New("The node keys of target are in collision.")
+	ErrInvalidWeight   = errors.New("The weight of target should be greater than 0.")
+	ErrInvalidArgument = errors.New("The argument is invalid.")
+	ErrSaturated       = errors.New("All targets are saturated.")
+	ErrUnhealthy       = errors.New("The target is unhealthy.")
+	ErrCheckInProgress = errors.New("The previous check is in progress.")
+	ErrOutlier         = errors.New("The target is an outlier.")
+	ErrCircuitOpen     = errors.New("The circuit breakers of all targets are open.")
+	ErrInternal        = errors.New("Occur internal error.")
+)
+
+// The error about the operation on a target.
+type TargetError struct {
+	Op     string
+	Target string
+	Err    error
+}
+
+func (self *TargetError) Error() string {
+	return fmt.Sprintf("%s (op=%s, target=%s)", self.Err, self.Op, self.Target)
+}
+
+func (self *TargetError) Unwrap() error {
+	return self.Err
+}
+
+// The error about an invalid argument. It wraps 'ErrInvalidArgument'.
+type ArgumentError struct {
+	Name   string
+	Value  interface{}
+	Reason string
+}
+
+func (self *ArgumentError) Error() string {
+	return fmt.Sprintf("The argument '%s' (%v) is invalid: %s", self.Name, self.Value, self.Reason)
+}
+
+func (self *ArgumentError) Unwrap() error {
+	return ErrInvalidArgument
+}
+
+// The error which is recovered from a panic. It wraps 'ErrInternal'.
+type PanicError struct {
+	Op    string
+	Value interface{}
+}
+
+func (self *PanicError) Error() string {
+	return fmt.Sprintf("Occur FATAL error when %s: %v", self.Op, self.Value)
+}
+
+func (self *PanicError) Unwrap() error {
+	return ErrInternal
+}
+
+// Recover from the panic and set it into the error as a 'PanicError'.
+// It should be deferred directly.
+func recoverError(op string, err *error, logger Logger) {
+	if p := recover(); p != nil {
+		panicError := &PanicError{Op: op, Value: p}
+		logger.Errorf("%s", panicError)
+		*err = panicError
+	}
+}
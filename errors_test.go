@@ -0,0 +1,77 @@
+package chash4go
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestHashRingErrors(t *testing.T) {
+	hashRings := []HashRing{&SimpleHashRing{}, &JumpHashRing{}, &RendezvousRing{}, &MaglevRing{}, &MultiProbeRing{}}
+	for _, hashRing := range hashRings {
+		if _, err := hashRing.GetTarget("chash_test"); !errors.Is(err, ErrNotBuilt) {
+			t.Errorf("Getting target from unbuilded %T should be '%v'. (but %v)", hashRing, ErrNotBuilt, err)
+			t.FailNow()
+		}
+		if err := hashRing.AddTarget("10.11.5.145:2181"); !errors.Is(err, ErrNotBuilt) {
+			t.Errorf("Adding target to unbuilded %T should be '%v'. (but %v)", hashRing, ErrNotBuilt, err)
+			t.FailNow()
+		}
+		if err := hashRing.Destroy(); !errors.Is(err, ErrNotBuilt) {
+			t.Errorf("Destroying unbuilded %T should be '%v'. (but %v)", hashRing, ErrNotBuilt, err)
+			t.FailNow()
+		}
+		hashRing.Build(0)
+		if err := hashRing.Build(0); !errors.Is(err, ErrAlreadyBuilt) {
+			t.Errorf("Rebuilding %T should be '%v'. (but %v)", hashRing, ErrAlreadyBuilt, err)
+			t.FailNow()
+		}
+		if _, err := hashRing.GetTarget("chash_test"); !errors.Is(err, ErrEmptyRing) {
+			t.Errorf("Getting target from empty %T should be '%v'. (but %v)", hashRing, ErrEmptyRing, err)
+			t.FailNow()
+		}
+		hashRing.AddTarget("10.11.5.145:2181")
+		err := hashRing.AddTarget("10.11.5.145:2181")
+		var targetError *TargetError
+		if !errors.Is(err, ErrTargetExists) || !errors.As(err, &targetError) || targetError.Target != "10.11.5.145:2181" {
+			t.Errorf("Adding target to %T again should be '%v'. (but %v)", hashRing, ErrTargetExists, err)
+			t.FailNow()
+		}
+		if err := hashRing.RemoveTarget("10.11.5.164:2181"); !errors.Is(err, ErrUnknownTarget) {
+			t.Errorf("Removing nonexistent target from %T should be '%v'. (but %v)", hashRing, ErrUnknownTarget, err)
+			t.FailNow()
+		}
+		err = hashRing.Check(func(target string) bool { panic("broken checker") })
+		var panicError *PanicError
+		if !errors.Is(err, ErrInternal) || !errors.As(err, &panicError) {
+			t.Errorf("Checking %T with panic should be '%v'. (but %v)", hashRing, ErrInternal, err)
+			t.FailNow()
+		}
+		if err := hashRing.Destroy(); err != nil {
+			t.Errorf("Destroying %T Error: %s", hashRing, err)
+			t.FailNow()
+		}
+	}
+}
+
+func TestSimpleHashRingErrors(t *testing.T) {
+	shr := SimpleHashRing{}
+	shr.Build(0)
+	err := shr.AddWeightedTarget("10.11.5.145:2181", 0)
+	if !errors.Is(err, ErrInvalidWeight) {
+		t.Errorf("Adding target with zero weight should be '%v'. (but %v)", ErrInvalidWeight, err)
+		t.FailNow()
+	}
+	if err := shr.SetWeight("10.11.5.145:2181", 200); !errors.Is(err, ErrUnknownTarget) {
+		t.Errorf("Setting weight of nonexistent target should be '%v'. (but %v)", ErrUnknownTarget, err)
+		t.FailNow()
+	}
+	var argumentError *ArgumentError
+	if err := shr.SetBalanceFactor(0.5); !errors.Is(err, ErrInvalidArgument) || !errors.As(err, &argumentError) {
+		t.Errorf("Setting balance factor 0.5 should be '%v'. (but %v)", ErrInvalidArgument, err)
+		t.FailNow()
+	}
+	if _, err := NewSimpleHashRing(WithShadowNumber(0)); !errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("Creating hash ring with zero shadow number should be '%v'. (but %v)", ErrInvalidArgument, err)
+		t.FailNow()
+	}
+}
@@ -0,0 +1,3 @@
+module github.com/hyper-carrot/chash4go
+
+go 1.21
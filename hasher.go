@@ -0,0 +1,257 @@
+package chash4go
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"hash/fnv"
+	"io"
+	"math/bits"
+)
+
+type Hasher interface {
+	GetHashBytes(content string) []byte
+}
+
+// Validate the hasher by the hash bytes of a probe content. At least 4 bytes
+// are needed for a ketama number.
+func validateHasher(hasher Hasher) error {
+	if hasher == nil {
+		return &ArgumentError{"hasher", hasher, "It is nil."}
+	}
+	if length := len(hasher.GetHashBytes("chash4go")); length < 4 {
+		return &ArgumentError{"hasher", hasher, fmt.Sprintf("The length of hash bytes (%d) should be at least 4.", length)}
+	}
+	return nil
+}
+
+// The hasher of MD5 which is used by the original ketama.
+type MD5Hasher struct{}
+
+func (self MD5Hasher) GetHashBytes(content string) []byte {
+	hash := md5.New()
+	io.WriteString(hash, content)
+	return hash.Sum(nil)
+}
+
+// The hasher of SHA-1 which is the default one.
+type SHA1Hasher struct{}
+
+func (self SHA1Hasher) GetHashBytes(content string) []byte {
+	hash := sha1.New()
+	io.WriteString(hash, content)
+	return hash.Sum(nil)
+}
+
+// The hasher of 64-bit FNV-1a.
+type FNV1aHasher struct{}
+
+func (self FNV1aHasher) GetHashBytes(content string) []byte {
+	hash := fnv.New64a()
+	io.WriteString(hash, content)
+	return hash.Sum(nil)
+}
+
+// The hasher of CRC-32 (IEEE).
+type CRC32Hasher struct{}
+
+func (self CRC32Hasher) GetHashBytes(content string) []byte {
+	hash := crc32.NewIEEE()
+	io.WriteString(hash, content)
+	return hash.Sum(nil)
+}
+
+// The hasher of 128-bit MurmurHash3 (x64). The bytes are 'h1' & 'h2' in little-endian.
+type Murmur3Hasher struct {
+	Seed uint64
+}
+
+func (self Murmur3Hasher) GetHashBytes(content string) []byte {
+	h1, h2 := GetMurmur3Hash128([]byte(content), self.Seed)
+	bytes := make([]byte, 16)
+	binary.LittleEndian.PutUint64(bytes[:8], h1)
+	binary.LittleEndian.PutUint64(bytes[8:], h2)
+	return bytes
+}
+
+// The hasher of xxHash64. The bytes are in the canonical (big-endian) representation.
+type XXHash64Hasher struct {
+	Seed uint64
+}
+
+func (self XXHash64Hasher) GetHashBytes(content string) []byte {
+	bytes := make([]byte, 8)
+	binary.BigEndian.PutUint64(bytes, GetXXHash64([]byte(content), self.Seed))
+	return bytes
+}
+
+// Get the ketama numbers from the hash bytes of hasher.
+// The length of them is a quarter of the bytes length (at most 4).
+func GetKetamaNumbersByHasher(hasher Hasher, content string) []uint64 {
+	bytes := hasher.GetHashBytes(content)
+	length := len(bytes) / 4
+	if length > KETAMA_NUMBERS_LENGTH {
+		length = KETAMA_NUMBERS_LENGTH
+	}
+	ketamaNumbers := make([]uint64, length)
+	for i := 0; i < length; i++ {
+		ketamaNumbers[i] = uint64(binary.LittleEndian.Uint32(bytes[i*4:]))
+	}
+	return ketamaNumbers
+}
+
+// Get the legacy hash of key which is the average of ketama numbers.
+// Note that it is not uniform over the node space.
+func GetHashForKeyByHasher(hasher Hasher, content string) uint64 {
+	hashNumbers := GetKetamaNumbersByHasher(hasher, content)
+	var hash uint64
+	for _, n := range hashNumbers {
+		hash += n
+	}
+	return hash / uint64(len(hashNumbers))
+}
+
+// Get the hash of key which is the first ketama number. It is uniform over the node space.
+func GetUniformHashForKeyByHasher(hasher Hasher, content string) uint64 {
+	return GetKetamaNumbersByHasher(hasher, content)[0]
+}
+
+const (
+	murmur3C1 uint64 = 0x87c37b91114253d5
+	murmur3C2 uint64 = 0x4cf5ad432745937f
+)
+
+func murmur3Fmix64(k uint64) uint64 {
+	k ^= k >> 33
+	k *= 0xff51afd7ed558ccd
+	k ^= k >> 33
+	k *= 0xc4ceb9fe1a85ec53
+	k ^= k >> 33
+	return k
+}
+
+// Get the 128-bit MurmurHash3 (x64) of data.
+func GetMurmur3Hash128(data []byte, seed uint64) (uint64, uint64) {
+	h1, h2 := seed, seed
+	length := len(data)
+	blockNumber := length / 16
+	for i := 0; i < blockNumber; i++ {
+		k1 := binary.LittleEndian.Uint64(data[i*16:])
+		k2 := binary.LittleEndian.Uint64(data[i*16+8:])
+		k1 *= murmur3C1
+		k1 = bits.RotateLeft64(k1, 31)
+		k1 *= murmur3C2
+		h1 ^= k1
+		h1 = bits.RotateLeft64(h1, 27)
+		h1 += h2
+		h1 = h1*5 + 0x52dce729
+		k2 *= murmur3C2
+		k2 = bits.RotateLeft64(k2, 33)
+		k2 *= murmur3C1
+		h2 ^= k2
+		h2 = bits.RotateLeft64(h2, 31)
+		h2 += h1
+		h2 = h2*5 + 0x38495ab5
+	}
+	tail := data[blockNumber*16:]
+	var k1, k2 uint64
+	for i := len(tail) - 1; i >= 8; i-- {
+		k2 ^= uint64(tail[i]) << (uint(i-8) * 8)
+	}
+	if len(tail) > 8 {
+		k2 *= murmur3C2
+		k2 = bits.RotateLeft64(k2, 33)
+		k2 *= murmur3C1
+		h2 ^= k2
+	}
+	k1Length := len(tail)
+	if k1Length > 8 {
+		k1Length = 8
+	}
+	for i := k1Length - 1; i >= 0; i-- {
+		k1 ^= uint64(tail[i]) << (uint(i) * 8)
+	}
+	if len(tail) > 0 {
+		k1 *= murmur3C1
+		k1 = bits.RotateLeft64(k1, 31)
+		k1 *= murmur3C2
+		h1 ^= k1
+	}
+	h1 ^= uint64(length)
+	h2 ^= uint64(length)
+	h1 += h2
+	h2 += h1
+	h1 = murmur3Fmix64(h1)
+	h2 = murmur3Fmix64(h2)
+	h1 += h2
+	h2 += h1
+	return h1, h2
+}
+
+const (
+	xxHashPrime1 uint64 = 11400714785074694791
+	xxHashPrime2 uint64 = 14029467366897019727
+	xxHashPrime3 uint64 = 1609587929392839161
+	xxHashPrime4 uint64 = 9650029242287828579
+	xxHashPrime5 uint64 = 2870177450012600261
+)
+
+func xxHashRound(acc uint64, input uint64) uint64 {
+	acc += input * xxHashPrime2
+	acc = bits.RotateLeft64(acc, 31)
+	return acc * xxHashPrime1
+}
+
+func xxHashMergeRound(acc uint64, val uint64) uint64 {
+	acc ^= xxHashRound(0, val)
+	return acc*xxHashPrime1 + xxHashPrime4
+}
+
+// Get the xxHash64 of data.
+func GetXXHash64(data []byte, seed uint64) uint64 {
+	length := len(data)
+	var h uint64
+	if length >= 32 {
+		v1 := seed + xxHashPrime1 + xxHashPrime2
+		v2 := seed + xxHashPrime2
+		v3 := seed
+		v4 := seed - xxHashPrime1
+		for len(data) >= 32 {
+			v1 = xxHashRound(v1, binary.LittleEndian.Uint64(data[0:]))
+			v2 = xxHashRound(v2, binary.LittleEndian.Uint64(data[8:]))
+			v3 = xxHashRound(v3, binary.LittleEndian.Uint64(data[16:]))
+			v4 = xxHashRound(v4, binary.LittleEndian.Uint64(data[24:]))
+			data = data[32:]
+		}
+		h = bits.RotateLeft64(v1, 1) + bits.RotateLeft64(v2, 7) + bits.RotateLeft64(v3, 12) + bits.RotateLeft64(v4, 18)
+		h = xxHashMergeRound(h, v1)
+		h = xxHashMergeRound(h, v2)
+		h = xxHashMergeRound(h, v3)
+		h = xxHashMergeRound(h, v4)
+	} else {
+		h = seed + xxHashPrime5
+	}
+	h += uint64(length)
+	for len(data) >= 8 {
+		h ^= xxHashRound(0, binary.LittleEndian.Uint64(data))
+		h = bits.RotateLeft64(h, 27)*xxHashPrime1 + xxHashPrime4
+		data = data[8:]
+	}
+	if len(data) >= 4 {
+		h ^= uint64(binary.LittleEndian.Uint32(data)) * xxHashPrime1
+		h = bits.RotateLeft64(h, 23)*xxHashPrime2 + xxHashPrime3
+		data = data[4:]
+	}
+	for _, b := range data {
+		h ^= uint64(b) * xxHashPrime5
+		h = bits.RotateLeft64(h, 11) * xxHashPrime1
+	}
+	h ^= h >> 33
+	h *= xxHashPrime2
+	h ^= h >> 29
+	h *= xxHashPrime3
+	h ^= h >> 32
+	return h
+}
@@ -0,0 +1,107 @@
+package chash4go
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"testing"
+)
+
+func TestHashers(t *testing.T) {
+	content := "The quick brown fox jumps over the lazy dog"
+	cases := []struct {
+		hasher        Hasher
+		expectedBytes string
+	}{
+		{MD5Hasher{}, "9e107d9d372bb6826bd81d3542a419d6"},
+		{SHA1Hasher{}, "2fd4e1c67a2d28fced849ee1bb76e7391b93eb12"},
+		{FNV1aHasher{}, "f3f9b7f5e7e47110"},
+		{CRC32Hasher{}, "414fa339"},
+		{Murmur3Hasher{}, "6c1b07bc7bbc4be347939ac4a93c437a"},
+		{XXHash64Hasher{}, "0b242d361fda71bc"},
+	}
+	for _, c := range cases {
+		bytes := fmt.Sprintf("%x", c.hasher.GetHashBytes(content))
+		if bytes != c.expectedBytes {
+			t.Errorf("The hash bytes of hasher %T should be %s. (but %s) ", c.hasher, c.expectedBytes, bytes)
+			t.FailNow()
+		}
+	}
+}
+
+func TestGetXXHash64(t *testing.T) {
+	cases := map[string]uint64{
+		"":    0xef46db3751d8e999,
+		"abc": 0x44bc2cf5ad770999,
+		"Nobody inspects the spammish repetition": 0xfbcea83c8a378bf1,
+	}
+	for content, expectedHash := range cases {
+		hash := GetXXHash64([]byte(content), 0)
+		if hash != expectedHash {
+			t.Errorf("The xxHash64 of content '%v' should be %x. (but %x) ", content, expectedHash, hash)
+			t.FailNow()
+		}
+	}
+}
+
+func TestSimpleHashRingWithHasher(t *testing.T) {
+	servers := [...]string{"10.11.156.71:2181", "10.11.5.145:2181", "10.11.5.164:2181", "192.168.106.63:2181", "192.168.106.64:2181"}
+	defaultRing := SimpleHashRing{}
+	sha1Ring := SimpleHashRing{NodeHasher: SHA1Hasher{}, KeyHasher: SHA1Hasher{}}
+	defaultRing.Build(100)
+	sha1Ring.Build(100)
+	for _, s := range servers {
+		defaultRing.AddTarget(s)
+		sha1Ring.AddTarget(s)
+	}
+	for i := 0; i < 1000; i++ {
+		key := "key-" + strconv.Itoa(i)
+		target1, _ := defaultRing.GetTarget(key)
+		target2, _ := sha1Ring.GetTarget(key)
+		if target1 != target2 {
+			t.Errorf("The target '%s' of key '%s' should be '%s' with SHA-1 hasher.", target2, key, target1)
+			t.FailNow()
+		}
+	}
+	hashers := []Hasher{MD5Hasher{}, FNV1aHasher{}, CRC32Hasher{}, Murmur3Hasher{}, XXHash64Hasher{}}
+	for _, hasher := range hashers {
+		shr := SimpleHashRing{NodeHasher: hasher, KeyHasher: hasher}
+		shr.Build(100)
+		for _, s := range servers {
+			if err := shr.AddTarget(s); err != nil {
+				t.Errorf("Adding server Error: %s", err)
+				t.FailNow()
+			}
+		}
+		counts := make(map[string]int)
+		for i := 0; i < 10000; i++ {
+			target, _ := shr.GetTarget("key-" + strconv.Itoa(i))
+			counts[target]++
+		}
+		t.Logf("The key counts with hasher %T: %v", hasher, counts)
+		if len(counts) != len(servers) {
+			t.Errorf("The keys should be located at all servers with hasher %T. (counts=%v)", hasher, counts)
+			t.FailNow()
+		}
+	}
+}
+
+type shortHasher struct{}
+
+func (self shortHasher) GetHashBytes(content string) []byte {
+	return []byte{byte(len(content)), 0}
+}
+
+func TestSimpleHashRingWithShortHasher(t *testing.T) {
+	for i, opt := range []Option{WithHasher(shortHasher{}), WithNodeHasher(shortHasher{}), WithKeyHasher(shortHasher{})} {
+		if _, err := NewSimpleHashRing(opt); !errors.Is(err, ErrInvalidArgument) {
+			t.Errorf("Creating hash ring with short hasher should be '%v'. (but %v, case=%d)", ErrInvalidArgument, err, i)
+			t.FailNow()
+		}
+	}
+	shr := SimpleHashRing{KeyHasher: shortHasher{}}
+	if err := shr.Build(10); !errors.Is(err, ErrInvalidArgument) || shr.Status() == BUILDED {
+		t.Errorf("Building hash ring with short hasher should be '%v'. (but %v)", ErrInvalidArgument, err)
+		t.FailNow()
+	}
+}
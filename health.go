@@ -0,0 +1,361 @@
+package chash4go
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// The function of health check. The target is unhealthy if an error is returned,
+// and the error is recorded as the ejection reason of target.
+type HealthCheckFunc func(ctx context.Context, target string) error
+
+// The default timeout of each health check.
+const DEFAULT_CHECK_TIMEOUT = time.Second
+
+// The default number of health checks which run at the same time.
+const DEFAULT_CHECK_CONCURRENCY = 8
+
+// The default number of consecutive failures or successes which changes the state of target.
+const DEFAULT_CHECK_THRESHOLD = 1
+
+// The health state of target which is tracked by the checks. The target is
+// ejected after 'fall' consecutive failures, and re-admitted after 'rise'
+// consecutive successes.
+type TargetHealth struct {
+	ConsecutiveFailures  int
+	ConsecutiveSuccesses int
+	// The error of the last failed check.
+	Reason error
+	// The penalty & suppression of flap damping.
+	Penalty     float64
+	Suppressed  bool
+	penaltyTime time.Time
+	// The time until which the target is ejected by outlier detection.
+	EjectedUntil  time.Time
+	ejectionCount int
+}
+
+// Convert the node check function to the health check function.
+// The error is 'ErrUnhealthy' if the target is invalid.
+func toHealthCheckFunc(nodeCheckFunc NodeCheckFunc) HealthCheckFunc {
+	return func(ctx context.Context, target string) error {
+		if !nodeCheckFunc(target) {
+			return ErrUnhealthy
+		}
+		return nil
+	}
+}
+
+// The result of a health check. The panic of check function is kept in it.
+type checkResult struct {
+	err      error
+	panicked interface{}
+}
+
+// Run the health check of target with timeout. There is no timeout if it is not
+// positive. The check is abandoned when the context is done even if the function
+// ignores the context.
+func runHealthCheck(ctx context.Context, timeout time.Duration, healthCheckFunc HealthCheckFunc, target string) checkResult {
+	var checkCtx context.Context
+	var cancel context.CancelFunc
+	if timeout > 0 {
+		checkCtx, cancel = context.WithTimeout(ctx, timeout)
+	} else {
+		checkCtx, cancel = context.WithCancel(ctx)
+	}
+	defer cancel()
+	resultChan := make(chan checkResult, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				resultChan <- checkResult{panicked: p}
+			}
+		}()
+		resultChan <- checkResult{err: healthCheckFunc(checkCtx, target)}
+	}()
+	select {
+	case result := <-resultChan:
+		return result
+	case <-checkCtx.Done():
+		return checkResult{err: checkCtx.Err()}
+	}
+}
+
+// Run the health checks of targets by the workers, the number of which is at most
+// the concurrency. The checks which are not started are skipped if the context is done.
+func runHealthChecks(ctx context.Context, timeout time.Duration, concurrency int, healthCheckFunc HealthCheckFunc, targets []string) []checkResult {
+	results := make([]checkResult, len(targets))
+	if concurrency > len(targets) {
+		concurrency = len(targets)
+	}
+	indexChan := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range indexChan {
+				results[index] = runHealthCheck(ctx, timeout, healthCheckFunc, targets[index])
+			}
+		}()
+	}
+	for index := range targets {
+		if ctx.Err() != nil {
+			break
+		}
+		indexChan <- index
+	}
+	close(indexChan)
+	wg.Wait()
+	return results
+}
+
+// Check the targets by the health check function in parallel. Each check has a
+// timeout, and the error of check is recorded as the ejection reason of target.
+// The results are applied to the ring in one change after all checks are finished,
+// and nothing is changed if the context is done before that. The cycle is skipped
+// with 'ErrCheckInProgress' if the previous one is not finished.
+func (self *SimpleHashRing) HealthCheck(ctx context.Context, healthCheckFunc HealthCheckFunc) error {
+	timeout := self.checkTimeout
+	if timeout <= 0 {
+		timeout = DEFAULT_CHECK_TIMEOUT
+	}
+	return self.healthCheck(ctx, healthCheckFunc, timeout)
+}
+
+// Check the targets with the timeout. There is no timeout if it is not positive.
+func (self *SimpleHashRing) healthCheck(ctx context.Context, healthCheckFunc HealthCheckFunc, timeout time.Duration) (err error) {
+	if !atomic.CompareAndSwapInt32(&self.checking, 0, 1) {
+		return ErrCheckInProgress
+	}
+	defer atomic.StoreInt32(&self.checking, 0)
+	defer recoverError("check node ring", &err, self.getLogger())
+	self.changeLock.RLock()
+	if self.status != BUILDED {
+		self.changeLock.RUnlock()
+		return ErrNotBuilt
+	}
+	activeTargets := self.getActiveTargets()
+	pendingTargets := self.getPendingTargets()
+	self.changeLock.RUnlock()
+	concurrency := self.checkConcurrency
+	if concurrency <= 0 {
+		concurrency = DEFAULT_CHECK_CONCURRENCY
+	}
+	targets := make([]string, 0, len(activeTargets)+len(pendingTargets))
+	targets = append(targets, activeTargets...)
+	targets = append(targets, pendingTargets...)
+	results := runHealthChecks(ctx, timeout, concurrency, healthCheckFunc, targets)
+	resultMap := make(map[string]error, len(targets))
+	for i, result := range results {
+		if result.panicked != nil {
+			panic(result.panicked)
+		}
+		resultMap[targets[i]] = result.err
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	events := make([]RingEvent, 0)
+	defer func() {
+		self.fireEvents(events)
+	}()
+	self.changeLock.Lock()
+	defer self.changeLock.Unlock()
+	if self.status != BUILDED {
+		return ErrNotBuilt
+	}
+	changed := false
+	// The node ring is copied once before the first change.
+	changeNodeRing := func() *NodeRing {
+		if !changed {
+			self.nodeRing = self.nodeRing.Clone()
+			changed = true
+		}
+		return self.nodeRing
+	}
+	riseThreshold, fallThreshold := self.getCheckThresholds()
+	now := time.Now()
+	// The results are recorded before the panic mode is updated, so that the
+	// targets are counted by their health after the thresholds.
+	healthMap := make(map[string]*TargetHealth, len(targets))
+	healthyNumber, totalNumber := 0, 0
+	for _, target := range activeTargets {
+		if _, active := self.targetMap[target]; !active {
+			continue
+		}
+		health := self.recordCheckResult(target, resultMap[target])
+		healthMap[target] = health
+		if !self.isManualState(target) {
+			totalNumber++
+			if health.ConsecutiveFailures < fallThreshold && !now.Before(health.EjectedUntil) {
+				healthyNumber++
+			}
+		}
+	}
+	for _, target := range pendingTargets {
+		if _, pending := self.pendingTargetMap[target]; !pending {
+			continue
+		}
+		health := self.recordCheckResult(target, resultMap[target])
+		healthMap[target] = health
+		if !self.isManualState(target) {
+			totalNumber++
+			if self.flapDamping != nil {
+				self.flapDamping.update(health, now, 0)
+			}
+			if health.ConsecutiveSuccesses >= riseThreshold && !health.Suppressed && !now.Before(health.EjectedUntil) {
+				healthyNumber++
+			}
+		}
+	}
+	if event := self.updatePanicMode(healthyNumber, totalNumber, now); event != nil {
+		events = append(events, *event)
+	}
+	for _, target := range activeTargets {
+		nodeKeys, active := self.targetMap[target]
+		health, checked := healthMap[target]
+		if !active || !checked {
+			continue
+		}
+		if self.inPanic || health.ConsecutiveFailures < fallThreshold {
+			continue
+		}
+		self.getLogger().Infof("Removing invalid target '%s'... (reason=%s)", target, health.Reason)
+		if self.removeNodeByKeys(changeNodeRing(), nodeKeys) {
+			self.pendingTargetMap[target] = nodeKeys
+			delete(self.targetMap, target)
+			if !self.isManualState(target) {
+				self.setTargetState(target, TARGET_DOWN, health.Reason.Error(), false, now)
+			}
+			health.ConsecutiveSuccesses = 0
+			if self.flapDamping != nil {
+				self.flapDamping.update(health, now, self.flapDamping.Penalty)
+			}
+		}
+	}
+	for _, target := range pendingTargets {
+		health, checked := healthMap[target]
+		if !checked || self.isManualState(target) {
+			continue
+		}
+		if self.inPanic {
+			self.getLogger().Infof("Adding target '%s' in panic mode...", target)
+			changeNodeRing()
+			if self.readmitTarget(target, now) {
+				self.setTargetState(target, TARGET_ACTIVE, "panic mode", false, now)
+			}
+			continue
+		}
+		if health.ConsecutiveSuccesses < riseThreshold || now.Before(health.EjectedUntil) {
+			continue
+		}
+		if self.flapDamping != nil {
+			self.flapDamping.update(health, now, 0)
+			if health.Suppressed {
+				self.getLogger().Infof("The valid target '%s' is suppressed. (penalty=%f)", target, health.Penalty)
+				continue
+			}
+		}
+		self.getLogger().Infof("Adding valid target '%s'...", target)
+		changeNodeRing()
+		if self.readmitTarget(target, now) {
+			health.ConsecutiveFailures = 0
+			health.Reason = nil
+			health.EjectedUntil = time.Time{}
+			self.setTargetState(target, TARGET_ACTIVE, "", false, now)
+		}
+	}
+	if changed {
+		self.publish()
+	}
+	return nil
+}
+
+// Start the checker with the health check function. The running checks are
+// canceled by 'StopCheck'.
+func (self *SimpleHashRing) StartHealthCheck(healthCheckFunc HealthCheckFunc, intervalSeconds uint16) (bool, error) {
+	return self.startHealthCheck(healthCheckFunc, intervalSeconds, self.HealthCheck)
+}
+
+func (self *SimpleHashRing) startHealthCheck(healthCheckFunc HealthCheckFunc, intervalSeconds uint16,
+	check func(ctx context.Context, healthCheckFunc HealthCheckFunc) error) (done bool, err error) {
+	defer recoverError("start checker", &err, self.getLogger())
+	if self.Status() != BUILDED {
+		return false, ErrNotBuilt
+	}
+	newCheckFunc := func(ctx context.Context) CheckFunc {
+		return func() {
+			err := check(ctx, healthCheckFunc)
+			switch {
+			case err == nil || ctx.Err() != nil:
+			case errors.Is(err, ErrCheckInProgress):
+				self.getLogger().Warnf("The previous check is overrunning. Skip the current one.")
+			default:
+				self.getLogger().Errorf("Node ring checking is FAILING: %s", err)
+			}
+		}
+	}
+	return self.startContextChecker(newCheckFunc, intervalSeconds, self.getLogger()), nil
+}
+
+// Record the result of check into the health state of target.
+// It should be called with the lock held.
+func (self *SimpleHashRing) recordCheckResult(target string, err error) *TargetHealth {
+	health, exists := self.healthMap[target]
+	if !exists {
+		health = &TargetHealth{}
+		self.healthMap[target] = health
+	}
+	if err != nil {
+		health.ConsecutiveFailures++
+		health.ConsecutiveSuccesses = 0
+		health.Reason = err
+	} else {
+		health.ConsecutiveSuccesses++
+		health.ConsecutiveFailures = 0
+	}
+	return health
+}
+
+func (self *SimpleHashRing) getCheckThresholds() (int, int) {
+	riseThreshold, fallThreshold := self.riseThreshold, self.fallThreshold
+	if riseThreshold <= 0 {
+		riseThreshold = DEFAULT_CHECK_THRESHOLD
+	}
+	if fallThreshold <= 0 {
+		fallThreshold = DEFAULT_CHECK_THRESHOLD
+	}
+	return riseThreshold, fallThreshold
+}
+
+// Get the health state of target which is tracked by the checks.
+func (self *SimpleHashRing) GetTargetHealth(target string) (TargetHealth, bool) {
+	self.changeLock.RLock()
+	defer self.changeLock.RUnlock()
+	health, exists := self.healthMap[target]
+	if !exists {
+		return TargetHealth{}, false
+	}
+	result := *health
+	if self.flapDamping != nil {
+		self.flapDamping.update(&result, time.Now(), 0)
+	}
+	return result, true
+}
+
+// Get the reason why the target is ejected. It is nil if the target is not ejected by checks.
+func (self *SimpleHashRing) GetEjectionReason(target string) error {
+	self.changeLock.RLock()
+	defer self.changeLock.RUnlock()
+	if _, pending := self.pendingTargetMap[target]; !pending {
+		return nil
+	}
+	if health, exists := self.healthMap[target]; exists {
+		return health.Reason
+	}
+	return nil
+}
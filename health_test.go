@@ -0,0 +1,243 @@
+package chash4go
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSimpleHashRingWithHealthCheck(t *testing.T) {
+	servers := [...]string{"10.11.156.71:2181", "10.11.5.145:2181", "10.11.5.164:2181"}
+	shr, err := NewSimpleHashRing(WithCheckTimeout(50 * time.Millisecond))
+	if err != nil {
+		t.Errorf("Creating hash ring Error: %s", err)
+		t.FailNow()
+	}
+	for _, s := range servers {
+		shr.AddTarget(s)
+	}
+	refusedError := errors.New("connection refused")
+	hungTarget := servers[1]
+	refusedTarget := servers[2]
+	healthCheckFunc := func(ctx context.Context, target string) error {
+		switch target {
+		case hungTarget:
+			// It ignores the context.
+			time.Sleep(time.Second)
+		case refusedTarget:
+			return refusedError
+		}
+		return nil
+	}
+	begin := time.Now()
+	err = shr.HealthCheck(context.Background(), healthCheckFunc)
+	if err != nil {
+		t.Errorf("Health checking Error: %s", err)
+		t.FailNow()
+	}
+	if cost := time.Since(begin); cost > 500*time.Millisecond {
+		t.Errorf("The hung target should not stall the check. (cost=%v)", cost)
+		t.FailNow()
+	}
+	if reason := shr.GetEjectionReason(hungTarget); !errors.Is(reason, context.DeadlineExceeded) {
+		t.Errorf("The ejection reason of target '%s' should be '%v'. (but %v)", hungTarget, context.DeadlineExceeded, reason)
+		t.FailNow()
+	}
+	if reason := shr.GetEjectionReason(refusedTarget); !errors.Is(reason, refusedError) {
+		t.Errorf("The ejection reason of target '%s' should be '%v'. (but %v)", refusedTarget, refusedError, reason)
+		t.FailNow()
+	}
+	if reason := shr.GetEjectionReason(servers[0]); reason != nil {
+		t.Errorf("The target '%s' should not have ejection reason. (but %v)", servers[0], reason)
+		t.FailNow()
+	}
+	shr.Check(func(target string) bool { return target != refusedTarget })
+	if reason := shr.GetEjectionReason(refusedTarget); !errors.Is(reason, ErrUnhealthy) {
+		t.Errorf("The ejection reason of target '%s' should be '%v'. (but %v)", refusedTarget, ErrUnhealthy, reason)
+		t.FailNow()
+	}
+	if reason := shr.GetEjectionReason(hungTarget); reason != nil || !shr.ContainsTarget(hungTarget) || len(shr.GetActiveTargets()) != 2 {
+		t.Errorf("The target '%s' should be re-admitted. (active=%v, reason=%v)", hungTarget, shr.GetActiveTargets(), reason)
+		t.FailNow()
+	}
+}
+
+func TestSimpleHashRingForSlowNodeCheck(t *testing.T) {
+	servers := [...]string{"10.11.5.145:2181", "10.11.5.164:2181"}
+	shr, _ := NewSimpleHashRing(WithShadowNumber(10))
+	for _, s := range servers {
+		shr.AddTarget(s)
+	}
+	// The node check has no timeout without 'WithCheckTimeout'.
+	err := shr.Check(func(target string) bool {
+		time.Sleep(DEFAULT_CHECK_TIMEOUT + 100*time.Millisecond)
+		return true
+	})
+	if err != nil {
+		t.Errorf("Checking Error: %s", err)
+		t.FailNow()
+	}
+	if len(shr.GetActiveTargets()) != len(servers) {
+		t.Errorf("The slow but valid targets should stay in the ring. (pending=%v)", shr.GetPendingTargets())
+		t.FailNow()
+	}
+}
+
+func TestSimpleHashRingForHealthCheckCancellation(t *testing.T) {
+	shr, _ := NewSimpleHashRing()
+	shr.AddTarget("10.11.5.145:2181")
+	ctx, cancel := context.WithCancel(context.Background())
+	startedChan := make(chan bool, 1)
+	go func() {
+		<-startedChan
+		cancel()
+	}()
+	err := shr.HealthCheck(ctx, func(ctx context.Context, target string) error {
+		startedChan <- true
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("The canceled check should be '%v'. (but %v)", context.Canceled, err)
+		t.FailNow()
+	}
+	if len(shr.GetActiveTargets()) != 1 {
+		t.Errorf("The canceled check should not eject any target. (pending=%v)", shr.GetPendingTargets())
+		t.FailNow()
+	}
+	canceledChan := make(chan error, 1)
+	shr.StartHealthCheck(func(ctx context.Context, target string) error {
+		<-ctx.Done()
+		canceledChan <- ctx.Err()
+		return ctx.Err()
+	}, uint16(1))
+	time.Sleep(1200 * time.Millisecond)
+	shr.StopCheck()
+	select {
+	case err := <-canceledChan:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("The check should be canceled by stopping checker. (err=%v)", err)
+			t.FailNow()
+		}
+	case <-time.After(time.Second):
+		t.Errorf("The check is not canceled by stopping checker.")
+		t.FailNow()
+	}
+	if len(shr.GetActiveTargets()) != 1 {
+		t.Errorf("The canceled check should not eject any target. (pending=%v)", shr.GetPendingTargets())
+		t.FailNow()
+	}
+}
+
+func TestSimpleHashRingForParallelHealthCheck(t *testing.T) {
+	concurrency := 4
+	shr, _ := NewSimpleHashRing(WithShadowNumber(10), WithCheckConcurrency(concurrency), WithCheckTimeout(time.Second))
+	targetNumber := 16
+	for i := 0; i < targetNumber; i++ {
+		shr.AddTarget("10.11.5." + strconv.Itoa(i) + ":2181")
+	}
+	var running, maxRunning int32
+	releaseChan := make(chan bool)
+	healthCheckFunc := func(ctx context.Context, target string) error {
+		current := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			max := atomic.LoadInt32(&maxRunning)
+			if current <= max || atomic.CompareAndSwapInt32(&maxRunning, max, current) {
+				break
+			}
+		}
+		time.Sleep(50 * time.Millisecond)
+		<-releaseChan
+		return errors.New("unhealthy")
+	}
+	doneChan := make(chan error, 1)
+	go func() {
+		doneChan <- shr.HealthCheck(context.Background(), healthCheckFunc)
+	}()
+	time.Sleep(20 * time.Millisecond)
+	if err := shr.Check(func(target string) bool { return true }); !errors.Is(err, ErrCheckInProgress) {
+		t.Errorf("The overlapping check should be '%v'. (but %v)", ErrCheckInProgress, err)
+		t.FailNow()
+	}
+	if len(shr.GetPendingTargets()) != 0 {
+		t.Errorf("The targets should not be ejected before all checks are finished. (pending=%v)", shr.GetPendingTargets())
+		t.FailNow()
+	}
+	begin := time.Now()
+	close(releaseChan)
+	if err := <-doneChan; err != nil {
+		t.Errorf("Health checking Error: %s", err)
+		t.FailNow()
+	}
+	if cost := time.Since(begin); cost > 600*time.Millisecond {
+		t.Errorf("The checks should run in parallel. (cost=%v)", cost)
+		t.FailNow()
+	}
+	if maxRunning != int32(concurrency) {
+		t.Errorf("The max number %d of running checks should be %d.", maxRunning, concurrency)
+		t.FailNow()
+	}
+	if len(shr.GetPendingTargets()) != targetNumber {
+		t.Errorf("All targets should be ejected. (pending=%v)", shr.GetPendingTargets())
+		t.FailNow()
+	}
+}
+
+func TestSimpleHashRingWithCheckThresholds(t *testing.T) {
+	shr, _ := NewSimpleHashRing(WithShadowNumber(10), WithCheckThresholds(2, 3))
+	servers := [...]string{"10.11.5.145:2181", "10.11.5.164:2181"}
+	for _, s := range servers {
+		shr.AddTarget(s)
+	}
+	target := servers[0]
+	valid := true
+	nodeCheckFunc := func(server string) bool {
+		return server != target || valid
+	}
+	// The flapping target should not be ejected.
+	for i := 0; i < 9; i++ {
+		valid = i%3 == 2
+		shr.Check(nodeCheckFunc)
+		if len(shr.GetPendingTargets()) != 0 {
+			t.Errorf("The flapping target '%s' should not be ejected. (round=%d)", target, i)
+			t.FailNow()
+		}
+	}
+	cases := []struct {
+		valid                bool
+		expectedActive       bool
+		expectedFailures     int
+		expectedSuccesses    int
+		expectedActiveNumber int
+	}{
+		{false, true, 1, 0, 2},
+		{false, true, 2, 0, 2},
+		{false, false, 3, 0, 1},
+		{true, false, 0, 1, 1},
+		{false, false, 1, 0, 1},
+		{true, false, 0, 1, 1},
+		{true, true, 0, 2, 2},
+	}
+	for i, c := range cases {
+		valid = c.valid
+		shr.Check(nodeCheckFunc)
+		health, _ := shr.GetTargetHealth(target)
+		_, active := shr.GetWeight(target)
+		if active != c.expectedActive || health.ConsecutiveFailures != c.expectedFailures || health.ConsecutiveSuccesses != c.expectedSuccesses {
+			t.Errorf("The state (active=%v, health=%+v) of target '%s' is unexpected. (case=%d)", active, health, target, i)
+			t.FailNow()
+		}
+		if len(shr.GetActiveTargets()) != c.expectedActiveNumber {
+			t.Errorf("The number of active targets %v should be %d. (case=%d)", shr.GetActiveTargets(), c.expectedActiveNumber, i)
+			t.FailNow()
+		}
+	}
+	if _, err := NewSimpleHashRing(WithCheckThresholds(0, 1)); !errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("Creating hash ring with zero rise threshold should be '%v'. (but %v)", ErrInvalidArgument, err)
+		t.FailNow()
+	}
+}
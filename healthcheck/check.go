@@ -0,0 +1,45 @@
+// The ready-made health checks of targets ('host:port') for the hash rings.
+// The checks are 'chash4go.HealthCheckFunc', which are used by 'StartHealthCheck'.
+// The gRPC check over h2c is only built with Go 1.24 or later.
+package healthcheck
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hyper-carrot/chash4go"
+)
+
+// The default timeout of each check.
+const DEFAULT_TIMEOUT = time.Second
+
+// The error about an unexpected response of target. It wraps 'chash4go.ErrUnhealthy'.
+type ResponseError struct {
+	Protocol string
+	Response string
+}
+
+func (self *ResponseError) Error() string {
+	return fmt.Sprintf("The %s response '%s' is unexpected.", self.Protocol, self.Response)
+}
+
+func (self *ResponseError) Unwrap() error {
+	return chash4go.ErrUnhealthy
+}
+
+// Convert the health check to the node check which is used by 'StartCheck'.
+// The converted check runs with 'context.Background()', so it cannot be
+// cancelled by 'StopCheck'. Use 'StartHealthCheck' with the health check instead.
+func ToNodeCheckFunc(healthCheckFunc chash4go.HealthCheckFunc) chash4go.NodeCheckFunc {
+	return func(target string) bool {
+		return healthCheckFunc(context.Background(), target) == nil
+	}
+}
+
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		timeout = DEFAULT_TIMEOUT
+	}
+	return context.WithTimeout(ctx, timeout)
+}
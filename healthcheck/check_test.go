@@ -0,0 +1,34 @@
+package healthcheck
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/hyper-carrot/chash4go"
+)
+
+func TestToNodeCheckFunc(t *testing.T) {
+	nodeCheckFunc := ToNodeCheckFunc(func(ctx context.Context, target string) error {
+		if target != "10.11.5.145:2181" {
+			return &ResponseError{"test", target}
+		}
+		return nil
+	})
+	if !nodeCheckFunc("10.11.5.145:2181") {
+		t.Errorf("The healthy target should be valid.")
+		t.FailNow()
+	}
+	if nodeCheckFunc("10.11.5.164:2181") {
+		t.Errorf("The unhealthy target should be invalid.")
+		t.FailNow()
+	}
+}
+
+func TestResponseError(t *testing.T) {
+	var err error = &ResponseError{"HTTP", "503"}
+	if !errors.Is(err, chash4go.ErrUnhealthy) {
+		t.Errorf("The response error should be '%v'. (but %v)", chash4go.ErrUnhealthy, err)
+		t.FailNow()
+	}
+}
@@ -0,0 +1,156 @@
+//go:build go1.24
+
+package healthcheck
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/hyper-carrot/chash4go"
+)
+
+// The method of the standard gRPC health checking protocol.
+const GRPC_HEALTH_CHECK_PATH = "/grpc.health.v1.Health/Check"
+
+// The serving status of 'grpc.health.v1.HealthCheckResponse'.
+const (
+	GRPC_UNKNOWN         = 0
+	GRPC_SERVING         = 1
+	GRPC_NOT_SERVING     = 2
+	GRPC_SERVICE_UNKNOWN = 3
+)
+
+var errMalformedGRPCMessage = errors.New("The gRPC message is malformed.")
+
+// The client of gRPC over cleartext HTTP/2 (h2c).
+var grpcClient = newGRPCClient()
+
+func newGRPCClient() *http.Client {
+	protocols := new(http.Protocols)
+	protocols.SetUnencryptedHTTP2(true)
+	return &http.Client{Transport: &http.Transport{Protocols: protocols}}
+}
+
+// Create the check which calls the standard gRPC health checking protocol
+// ('grpc.health.v1.Health/Check') of the target over cleartext HTTP/2.
+// The service is the one of the request, and it is the whole server if empty.
+func NewGRPCCheck(service string, timeout time.Duration) chash4go.HealthCheckFunc {
+	body := encodeGRPCMessage(encodeHealthCheckRequest(service))
+	return func(ctx context.Context, target string) error {
+		ctx, cancel := withTimeout(ctx, timeout)
+		defer cancel()
+		request, err := http.NewRequestWithContext(ctx, http.MethodPost, "http://"+target+GRPC_HEALTH_CHECK_PATH, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		request.Header.Set("Content-Type", "application/grpc")
+		request.Header.Set("TE", "trailers")
+		response, err := grpcClient.Do(request)
+		if err != nil {
+			return err
+		}
+		defer response.Body.Close()
+		if response.StatusCode != http.StatusOK {
+			return &ResponseError{"gRPC", "HTTP " + strconv.Itoa(response.StatusCode)}
+		}
+		payload, err := io.ReadAll(io.LimitReader(response.Body, MAX_BODY_SIZE))
+		if err != nil {
+			return err
+		}
+		// The status is in the headers of the trailers-only response.
+		grpcStatus := response.Trailer.Get("Grpc-Status")
+		if grpcStatus == "" {
+			grpcStatus = response.Header.Get("Grpc-Status")
+		}
+		if grpcStatus != "0" {
+			return &ResponseError{"gRPC", "status " + grpcStatus}
+		}
+		message, err := decodeGRPCMessage(payload)
+		if err != nil {
+			return err
+		}
+		servingStatus, err := decodeHealthCheckResponse(message)
+		if err != nil {
+			return err
+		}
+		if servingStatus != GRPC_SERVING {
+			return &ResponseError{"gRPC", "serving status " + strconv.FormatUint(servingStatus, 10)}
+		}
+		return nil
+	}
+}
+
+// Encode 'HealthCheckRequest { string service = 1; }'.
+func encodeHealthCheckRequest(service string) []byte {
+	if service == "" {
+		return nil
+	}
+	message := []byte{0x0a}
+	message = binary.AppendUvarint(message, uint64(len(service)))
+	return append(message, service...)
+}
+
+// Decode the status of 'HealthCheckResponse { ServingStatus status = 1; }'.
+// The unknown fields are skipped.
+func decodeHealthCheckResponse(message []byte) (uint64, error) {
+	status := uint64(GRPC_UNKNOWN)
+	for len(message) > 0 {
+		tag, n := binary.Uvarint(message)
+		if n <= 0 {
+			return 0, errMalformedGRPCMessage
+		}
+		message = message[n:]
+		var value uint64
+		switch tag & 0x7 {
+		case 0:
+			value, n = binary.Uvarint(message)
+		case 1:
+			n = 8
+		case 2:
+			var length uint64
+			length, n = binary.Uvarint(message)
+			if n > 0 {
+				if length > uint64(len(message)-n) {
+					return 0, errMalformedGRPCMessage
+				}
+				n += int(length)
+			}
+		case 5:
+			n = 4
+		default:
+			return 0, errMalformedGRPCMessage
+		}
+		if n <= 0 || n > len(message) {
+			return 0, errMalformedGRPCMessage
+		}
+		message = message[n:]
+		if tag == 0x08 {
+			status = value
+		}
+	}
+	return status, nil
+}
+
+// Frame the message by the gRPC length-prefixed format without compression.
+func encodeGRPCMessage(message []byte) []byte {
+	frame := make([]byte, 5, 5+len(message))
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(message)))
+	return append(frame, message...)
+}
+
+func decodeGRPCMessage(frame []byte) ([]byte, error) {
+	if len(frame) < 5 || frame[0] != 0 {
+		return nil, errMalformedGRPCMessage
+	}
+	length := binary.BigEndian.Uint32(frame[1:5])
+	if uint64(length) != uint64(len(frame)-5) {
+		return nil, errMalformedGRPCMessage
+	}
+	return frame[5:], nil
+}
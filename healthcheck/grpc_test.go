@@ -0,0 +1,134 @@
+//go:build go1.24
+
+package healthcheck
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/hyper-carrot/chash4go"
+)
+
+// Serve the gRPC health checking protocol over h2c on a local listener. The
+// serving status of each service is in the map, and the unknown service is 'NOT_FOUND'.
+func serveGRPCHealth(t *testing.T, statusMap map[string]uint64) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listening Error: %s", err)
+	}
+	protocols := new(http.Protocols)
+	protocols.SetUnencryptedHTTP2(true)
+	server := &http.Server{
+		Protocols: protocols,
+		Handler: http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			if request.ProtoMajor != 2 || request.URL.Path != GRPC_HEALTH_CHECK_PATH ||
+				request.Header.Get("Content-Type") != "application/grpc" {
+				writer.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			frame, _ := io.ReadAll(request.Body)
+			message, err := decodeGRPCMessage(frame)
+			if err != nil || len(message) > 0 && message[0] != 0x0a {
+				writer.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			service := ""
+			if len(message) > 0 {
+				service = string(message[2:])
+			}
+			writer.Header().Set("Content-Type", "application/grpc")
+			status, exists := statusMap[service]
+			if !exists {
+				// The trailers-only response.
+				writer.Header().Set("Grpc-Status", "5")
+				writer.WriteHeader(http.StatusOK)
+				return
+			}
+			writer.Header().Set("Trailer", "Grpc-Status")
+			writer.WriteHeader(http.StatusOK)
+			response := []byte{0x08, byte(status)}
+			if status == GRPC_UNKNOWN {
+				response = nil
+			}
+			writer.Write(encodeGRPCMessage(response))
+			writer.Header().Set("Grpc-Status", "0")
+		}),
+	}
+	go server.Serve(listener)
+	t.Cleanup(func() { server.Close() })
+	return listener.Addr().String()
+}
+
+func TestGRPCCheck(t *testing.T) {
+	target := serveGRPCHealth(t, map[string]uint64{
+		"":               GRPC_SERVING,
+		"chash4go.Cache": GRPC_SERVING,
+		"chash4go.Store": GRPC_NOT_SERVING,
+		"chash4go.Queue": GRPC_UNKNOWN,
+	})
+	cases := []struct {
+		service  string
+		response string
+	}{
+		{"", ""},
+		{"chash4go.Cache", ""},
+		{"chash4go.Store", "serving status " + strconv.Itoa(GRPC_NOT_SERVING)},
+		{"chash4go.Queue", "serving status " + strconv.Itoa(GRPC_UNKNOWN)},
+		{"chash4go.Index", "status 5"},
+	}
+	for _, c := range cases {
+		err := NewGRPCCheck(c.service, time.Second)(context.Background(), target)
+		if c.response == "" {
+			if err != nil {
+				t.Errorf("Checking service '%s' Error: %s", c.service, err)
+				t.FailNow()
+			}
+			continue
+		}
+		var responseError *ResponseError
+		if !errors.As(err, &responseError) || responseError.Response != c.response || !errors.Is(err, chash4go.ErrUnhealthy) {
+			t.Errorf("The response of service '%s' should be '%s'. (err=%v)", c.service, c.response, err)
+			t.FailNow()
+		}
+	}
+	if err := NewGRPCCheck("", time.Second)(context.Background(), closedAddress(t)); err == nil {
+		t.Errorf("The closed target should be unhealthy.")
+		t.FailNow()
+	}
+}
+
+func TestGRPCMessage(t *testing.T) {
+	request := encodeHealthCheckRequest("chash4go.Cache")
+	expected := append([]byte{0x0a, 14}, "chash4go.Cache"...)
+	if !bytes.Equal(request, expected) {
+		t.Errorf("The request %v should be %v.", request, expected)
+		t.FailNow()
+	}
+	frame := encodeGRPCMessage(request)
+	message, err := decodeGRPCMessage(frame)
+	if err != nil || !bytes.Equal(message, request) {
+		t.Errorf("The decoded message %v should be %v. (err=%v)", message, request, err)
+		t.FailNow()
+	}
+	if _, err := decodeGRPCMessage(frame[:len(frame)-1]); err == nil {
+		t.Errorf("The truncated frame should be malformed.")
+		t.FailNow()
+	}
+	// The unknown fields (varint, fixed64, bytes & fixed32) are skipped.
+	response := []byte{0x10, 0x96, 0x01, 0x19, 1, 2, 3, 4, 5, 6, 7, 8, 0x22, 2, 'o', 'k', 0x2d, 1, 2, 3, 4, 0x08, GRPC_SERVING}
+	if status, err := decodeHealthCheckResponse(response); err != nil || status != GRPC_SERVING {
+		t.Errorf("The status %d of response should be %d. (err=%v)", status, GRPC_SERVING, err)
+		t.FailNow()
+	}
+	if _, err := decodeHealthCheckResponse([]byte{0x22, 5, 'o', 'k'}); err == nil {
+		t.Errorf("The truncated response should be malformed.")
+		t.FailNow()
+	}
+}
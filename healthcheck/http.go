@@ -0,0 +1,87 @@
+package healthcheck
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/hyper-carrot/chash4go"
+)
+
+// The max size of response body which is matched.
+const MAX_BODY_SIZE = 64 * 1024
+
+// The max size of response body which is kept in the error.
+const MAX_REPORTED_BODY_SIZE = 256
+
+/*
+ * The config of HTTP check. The target is requested by 'GET <Scheme>://<target><Path>'.
+ * The status of response should be 'ExpectedStatus' (or 2xx if it is zero), and
+ * the body should match 'BodyPattern' if it is set. The 'Host' header overrides
+ * the host of request for the virtual hosts.
+ */
+type HTTPCheckConfig struct {
+	Scheme         string
+	Path           string
+	Header         http.Header
+	ExpectedStatus int
+	BodyPattern    *regexp.Regexp
+	Timeout        time.Duration
+	Client         *http.Client
+}
+
+// Create the check which requests the target by HTTP GET.
+func NewHTTPCheck(config HTTPCheckConfig) chash4go.HealthCheckFunc {
+	scheme := config.Scheme
+	if scheme == "" {
+		scheme = "http"
+	}
+	path := config.Path
+	if path == "" {
+		path = "/"
+	}
+	client := config.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return func(ctx context.Context, target string) error {
+		ctx, cancel := withTimeout(ctx, config.Timeout)
+		defer cancel()
+		request, err := http.NewRequestWithContext(ctx, http.MethodGet, scheme+"://"+target+path, nil)
+		if err != nil {
+			return err
+		}
+		for name, values := range config.Header {
+			request.Header[name] = values
+		}
+		if host := config.Header.Get("Host"); host != "" {
+			request.Host = host
+		}
+		response, err := client.Do(request)
+		if err != nil {
+			return err
+		}
+		defer response.Body.Close()
+		if config.ExpectedStatus != 0 && response.StatusCode != config.ExpectedStatus ||
+			config.ExpectedStatus == 0 && response.StatusCode/100 != 2 {
+			return &ResponseError{"HTTP", strconv.Itoa(response.StatusCode)}
+		}
+		if config.BodyPattern == nil {
+			return nil
+		}
+		body, err := io.ReadAll(io.LimitReader(response.Body, MAX_BODY_SIZE))
+		if err != nil {
+			return err
+		}
+		if !config.BodyPattern.Match(body) {
+			if len(body) > MAX_REPORTED_BODY_SIZE {
+				body = append(body[:MAX_REPORTED_BODY_SIZE], "..."...)
+			}
+			return &ResponseError{"HTTP", string(body)}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,80 @@
+package healthcheck
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyper-carrot/chash4go"
+)
+
+func TestHTTPCheck(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		switch request.URL.Path {
+		case "/health":
+			if request.Header.Get("X-Check") != "chash4go" {
+				writer.WriteHeader(http.StatusForbidden)
+				return
+			}
+			writer.Write([]byte(`{"status":"UP"}`))
+		case "/down":
+			writer.Write([]byte(`{"status":"DOWN"}`))
+		case "/vhost":
+			if request.Host != "health.chash4go" {
+				writer.WriteHeader(http.StatusNotFound)
+			}
+		case "/large":
+			writer.Write([]byte(strings.Repeat("x", MAX_BODY_SIZE)))
+		case "/slow":
+			time.Sleep(500 * time.Millisecond)
+		default:
+			writer.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+	target := strings.TrimPrefix(server.URL, "http://")
+	pattern := regexp.MustCompile(`"status":"UP"`)
+	header := http.Header{"X-Check": []string{"chash4go"}}
+	cases := []struct {
+		config  HTTPCheckConfig
+		healthy bool
+	}{
+		{HTTPCheckConfig{Path: "/health", Header: header, BodyPattern: pattern}, true},
+		{HTTPCheckConfig{Path: "/health", BodyPattern: pattern}, false},
+		{HTTPCheckConfig{Path: "/health", Header: header, ExpectedStatus: http.StatusNoContent}, false},
+		{HTTPCheckConfig{Path: "/down"}, true},
+		{HTTPCheckConfig{Path: "/down", BodyPattern: pattern}, false},
+		{HTTPCheckConfig{}, false},
+		{HTTPCheckConfig{ExpectedStatus: http.StatusServiceUnavailable}, true},
+		{HTTPCheckConfig{Path: "/vhost", Header: http.Header{"Host": []string{"health.chash4go"}}}, true},
+		{HTTPCheckConfig{Path: "/vhost"}, false},
+	}
+	for i, c := range cases {
+		err := NewHTTPCheck(c.config)(context.Background(), target)
+		if c.healthy && err != nil || !c.healthy && !errors.Is(err, chash4go.ErrUnhealthy) {
+			t.Errorf("The result '%v' of check is unexpected. (case=%d)", err, i)
+			t.FailNow()
+		}
+	}
+	// The large body is cut in the error.
+	err := NewHTTPCheck(HTTPCheckConfig{Path: "/large", BodyPattern: pattern})(context.Background(), target)
+	var responseError *ResponseError
+	if !errors.As(err, &responseError) {
+		t.Errorf("The large body should be '%T'. (but %v)", responseError, err)
+		t.FailNow()
+	}
+	if len(responseError.Response) > MAX_REPORTED_BODY_SIZE+len("...") {
+		t.Errorf("The response in error should be cut to %d bytes. (but %d)", MAX_REPORTED_BODY_SIZE, len(responseError.Response))
+		t.FailNow()
+	}
+	err = NewHTTPCheck(HTTPCheckConfig{Path: "/slow", Timeout: 50 * time.Millisecond})(context.Background(), target)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("The slow target should be '%v'. (but %v)", context.DeadlineExceeded, err)
+		t.FailNow()
+	}
+}
@@ -0,0 +1,73 @@
+package healthcheck
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/hyper-carrot/chash4go"
+)
+
+// Create the check which connects to the target by TCP.
+func NewTCPCheck(timeout time.Duration) chash4go.HealthCheckFunc {
+	return func(ctx context.Context, target string) error {
+		ctx, cancel := withTimeout(ctx, timeout)
+		defer cancel()
+		conn, err := dial(ctx, target)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+}
+
+// Create the check which sends the request line to the target by TCP, and
+// expects that the response line has the prefix.
+func NewTextCheck(protocol string, request string, expectedPrefix string, timeout time.Duration) chash4go.HealthCheckFunc {
+	return func(ctx context.Context, target string) error {
+		ctx, cancel := withTimeout(ctx, timeout)
+		defer cancel()
+		conn, err := dial(ctx, target)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		if _, err := conn.Write([]byte(request)); err != nil {
+			return err
+		}
+		line, err := bufio.NewReader(conn).ReadString('\n')
+		if err != nil {
+			return err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if !strings.HasPrefix(line, expectedPrefix) {
+			return &ResponseError{protocol, line}
+		}
+		return nil
+	}
+}
+
+// Create the check which sends 'version' to the memcached target.
+func NewMemcachedCheck(timeout time.Duration) chash4go.HealthCheckFunc {
+	return NewTextCheck("memcached", "version\r\n", "VERSION ", timeout)
+}
+
+// Create the check which sends 'PING' to the Redis target.
+func NewRedisCheck(timeout time.Duration) chash4go.HealthCheckFunc {
+	return NewTextCheck("redis", "*1\r\n$4\r\nPING\r\n", "+PONG", timeout)
+}
+
+// Dial the target. The deadline of connection is the one of context.
+func dial(ctx context.Context, target string) (net.Conn, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", target)
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	return conn, nil
+}
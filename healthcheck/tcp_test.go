@@ -0,0 +1,126 @@
+package healthcheck
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hyper-carrot/chash4go"
+)
+
+// Serve the text protocol on a local listener. The handler returns the response line
+// of the request line, and nothing is responded if it is empty.
+func serveText(t *testing.T, handler func(line string) string) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listening Error: %s", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				reader := bufio.NewReader(conn)
+				for {
+					line, err := reader.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if response := handler(line); response != "" {
+						conn.Write([]byte(response))
+					}
+				}
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+// Get the address which is not listened.
+func closedAddress(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listening Error: %s", err)
+	}
+	address := listener.Addr().String()
+	listener.Close()
+	return address
+}
+
+func TestTCPCheck(t *testing.T) {
+	target := serveText(t, func(line string) string { return "" })
+	check := NewTCPCheck(time.Second)
+	if err := check(context.Background(), target); err != nil {
+		t.Errorf("Checking target '%s' Error: %s", target, err)
+		t.FailNow()
+	}
+	if err := check(context.Background(), closedAddress(t)); err == nil {
+		t.Errorf("The closed target should be unhealthy.")
+		t.FailNow()
+	}
+}
+
+func TestMemcachedCheck(t *testing.T) {
+	target := serveText(t, func(line string) string {
+		if line == "version\r\n" {
+			return "VERSION 1.6.21\r\n"
+		}
+		return "ERROR\r\n"
+	})
+	if err := NewMemcachedCheck(time.Second)(context.Background(), target); err != nil {
+		t.Errorf("Checking target '%s' Error: %s", target, err)
+		t.FailNow()
+	}
+	if err := NewRedisCheck(time.Second)(context.Background(), target); !errors.Is(err, chash4go.ErrUnhealthy) {
+		t.Errorf("The Redis check of memcached target should be '%v'. (but %v)", chash4go.ErrUnhealthy, err)
+		t.FailNow()
+	}
+}
+
+func TestRedisCheck(t *testing.T) {
+	var loading atomic.Bool
+	loading.Store(true)
+	target := serveText(t, func(line string) string {
+		switch {
+		case line != "PING\r\n":
+			return ""
+		case loading.Load():
+			return "-LOADING Redis is loading the dataset in memory\r\n"
+		}
+		return "+PONG\r\n"
+	})
+	check := NewRedisCheck(time.Second)
+	var responseError *ResponseError
+	if err := check(context.Background(), target); !errors.As(err, &responseError) || responseError.Protocol != "redis" {
+		t.Errorf("The loading target should be unhealthy. (err=%v)", err)
+		t.FailNow()
+	}
+	loading.Store(false)
+	if err := check(context.Background(), target); err != nil {
+		t.Errorf("Checking target '%s' Error: %s", target, err)
+		t.FailNow()
+	}
+}
+
+func TestTextCheckForTimeout(t *testing.T) {
+	target := serveText(t, func(line string) string { return "" })
+	begin := time.Now()
+	err := NewRedisCheck(100*time.Millisecond)(context.Background(), target)
+	var netError net.Error
+	if !errors.As(err, &netError) || !netError.Timeout() {
+		t.Errorf("The silent target should be timeout. (err=%v)", err)
+		t.FailNow()
+	}
+	if cost := time.Since(begin); cost > time.Second {
+		t.Errorf("The check should be stopped by the timeout. (cost=%v)", cost)
+		t.FailNow()
+	}
+}
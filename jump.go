@@ -0,0 +1,306 @@
+package chash4go
+
+import (
+	"sync"
+)
+
+type JumpHashMode string
+
+// Jump hash mode
+const (
+	// The original algorithm of "A Fast, Minimal Memory, Consistent Hash Algorithm" (Lamping & Veach).
+	LAMPING_VEACH JumpHashMode = "LAMPING_VEACH"
+	// The same as 'Hashing.consistentHash' of Guava.
+	GUAVA JumpHashMode = "GUAVA"
+)
+
+// Get the bucket of key hash by the jump consistent hash of Lamping & Veach.
+func GetJumpHash(keyHash uint64, bucketNumber int) int {
+	var b, j int64 = -1, 0
+	for j < int64(bucketNumber) {
+		b = j
+		keyHash = keyHash*2862933555777941757 + 1
+		j = int64(float64(b+1) * (float64(int64(1)<<31) / float64((keyHash>>33)+1)))
+	}
+	return int(b)
+}
+
+// Get the bucket of input by the same arithmetic as 'Hashing.consistentHash(long, int)' of Guava.
+func GetGuavaConsistentHash(input uint64, bucketNumber int) int {
+	if bucketNumber <= 0 {
+		return -1
+	}
+	state := input
+	candidate := int64(0)
+	for {
+		state = 2862933555777941757*state + 1
+		nextDouble := float64(int64(state>>33)+1) / float64(int64(1)<<31)
+		next := float64(candidate+1) / nextDouble
+		if next >= float64(bucketNumber) {
+			return int(candidate)
+		}
+		candidate = int64(next)
+	}
+}
+
+// The key hash which is the same as 'Hashing.sha1().hashString(key, UTF_8).padToLong()' of Guava.
+func GetJumpKeyHash(key string) uint64 {
+	return GetHash64(key)
+}
+
+/*
+ * A hash ring based on jump consistent hash. The targets are the buckets
+ * in the order of adding. Removing a non-tail target moves the tail target
+ * into its bucket, so only the keys of these two targets are moved.
+ * The targets which are ejected by checking keep their buckets, and the
+ * keys of them are rehashed to the other valid targets.
+ */
+type JumpHashRing struct {
+	Mode             JumpHashMode
+	KeyHashFunc      func(key string) uint64
+	buckets          []string
+	bucketMap        map[string]int
+	pendingTargetMap map[string]bool
+	changeLock       sync.RWMutex
+	status           HashRingStatus
+	ringLogger
+	ringChecker
+}
+
+var _ HashRing = (*JumpHashRing)(nil)
+
+func (self *JumpHashRing) initialize() {
+	self.buckets = make([]string, 0)
+	self.bucketMap = make(map[string]int)
+	self.pendingTargetMap = make(map[string]bool)
+	if len(self.Mode) == 0 {
+		self.Mode = LAMPING_VEACH
+	}
+	if self.KeyHashFunc == nil {
+		self.KeyHashFunc = GetJumpKeyHash
+	}
+	self.status = INITIALIZED
+}
+
+// The shadow number is ignored since there is no virtual node in jump hash ring.
+func (self *JumpHashRing) Build(shadowNumber uint16) error {
+	self.changeLock.Lock()
+	defer self.changeLock.Unlock()
+	switch self.status {
+	case "", UNINITIALIZED, DESTROYED:
+		self.initialize()
+		fallthrough
+	case INITIALIZED:
+		self.status = BUILDED
+	default:
+		return ErrAlreadyBuilt
+	}
+	return nil
+}
+
+func (self *JumpHashRing) Destroy() error {
+	self.StopCheck()
+	self.changeLock.Lock()
+	defer self.changeLock.Unlock()
+	switch self.status {
+	case INITIALIZED, BUILDED:
+		self.buckets = nil
+		self.bucketMap = nil
+		self.pendingTargetMap = nil
+		self.status = DESTROYED
+	default:
+		return ErrNotBuilt
+	}
+	return nil
+}
+
+func (self *JumpHashRing) Status() HashRingStatus {
+	self.changeLock.RLock()
+	defer self.changeLock.RUnlock()
+	if len(self.status) == 0 {
+		return UNINITIALIZED
+	}
+	return self.status
+}
+
+func (self *JumpHashRing) Check(nodeCheckFunc NodeCheckFunc) (err error) {
+	defer recoverError("check jump hash ring", &err, self.getLogger())
+	self.changeLock.RLock()
+	if self.status != BUILDED {
+		self.changeLock.RUnlock()
+		return ErrNotBuilt
+	}
+	targets := make([]string, len(self.buckets))
+	copy(targets, self.buckets)
+	self.changeLock.RUnlock()
+	for _, target := range targets {
+		valid := nodeCheckFunc(target)
+		self.changeLock.Lock()
+		_, exists := self.bucketMap[target]
+		pending := self.pendingTargetMap[target]
+		if exists && pending && valid {
+			self.getLogger().Infof("Adding valid target '%s'...", target)
+			delete(self.pendingTargetMap, target)
+		} else if exists && !pending && !valid {
+			self.getLogger().Infof("Removing invalid target '%s'...", target)
+			self.pendingTargetMap[target] = true
+		}
+		self.changeLock.Unlock()
+	}
+	return nil
+}
+
+func (self *JumpHashRing) StartCheck(nodeCheckFunc NodeCheckFunc, intervalSeconds uint16) (bool, error) {
+	if self.Status() != BUILDED {
+		return false, ErrNotBuilt
+	}
+	checkFunc := func() {
+		err := self.Check(nodeCheckFunc)
+		if err != nil {
+			self.getLogger().Errorf("Jump hash ring checking is FAILING: %s", err)
+		}
+	}
+	return self.startChecker(checkFunc, intervalSeconds, self.getLogger()), nil
+}
+
+func (self *JumpHashRing) AddTarget(target string) error {
+	self.changeLock.Lock()
+	defer self.changeLock.Unlock()
+	if self.status != BUILDED {
+		return ErrNotBuilt
+	}
+	if _, exists := self.bucketMap[target]; exists {
+		return &TargetError{"add target", target, ErrTargetExists}
+	}
+	self.bucketMap[target] = len(self.buckets)
+	self.buckets = append(self.buckets, target)
+	return nil
+}
+
+// Remove the target. The tail target will be moved into the bucket of
+// the removed target if the latter is not the tail one.
+func (self *JumpHashRing) RemoveTarget(target string) error {
+	self.changeLock.Lock()
+	defer self.changeLock.Unlock()
+	if self.status != BUILDED {
+		return ErrNotBuilt
+	}
+	index, exists := self.bucketMap[target]
+	if !exists {
+		return &TargetError{"remove target", target, ErrUnknownTarget}
+	}
+	tailIndex := len(self.buckets) - 1
+	if index != tailIndex {
+		tailTarget := self.buckets[tailIndex]
+		self.getLogger().Infof("Moving tail target '%s' into bucket %d...", tailTarget, index)
+		self.buckets[index] = tailTarget
+		self.bucketMap[tailTarget] = index
+	}
+	self.buckets = self.buckets[:tailIndex]
+	delete(self.bucketMap, target)
+	delete(self.pendingTargetMap, target)
+	return nil
+}
+
+func (self *JumpHashRing) ContainsTarget(target string) bool {
+	self.changeLock.RLock()
+	defer self.changeLock.RUnlock()
+	_, exists := self.bucketMap[target]
+	return exists
+}
+
+// Get the valid targets in the order of buckets.
+func (self *JumpHashRing) GetActiveTargets() []string {
+	self.changeLock.RLock()
+	defer self.changeLock.RUnlock()
+	targets := make([]string, 0, len(self.buckets))
+	for _, target := range self.buckets {
+		if !self.pendingTargetMap[target] {
+			targets = append(targets, target)
+		}
+	}
+	return targets
+}
+
+// Get the ejected targets in the order of buckets.
+func (self *JumpHashRing) GetPendingTargets() []string {
+	self.changeLock.RLock()
+	defer self.changeLock.RUnlock()
+	targets := make([]string, 0, len(self.pendingTargetMap))
+	for _, target := range self.buckets {
+		if self.pendingTargetMap[target] {
+			targets = append(targets, target)
+		}
+	}
+	return targets
+}
+
+// Get the targets in the order of buckets.
+func (self *JumpHashRing) GetBuckets() []string {
+	self.changeLock.RLock()
+	defer self.changeLock.RUnlock()
+	buckets := make([]string, len(self.buckets))
+	copy(buckets, self.buckets)
+	return buckets
+}
+
+func (self *JumpHashRing) GetTarget(key string) (string, error) {
+	results, err := self.GetTargets(key, 1)
+	if err != nil {
+		return "", err
+	}
+	if len(results) == 0 {
+		return "", nil
+	}
+	return results[0], nil
+}
+
+func (self *JumpHashRing) GetTargets(key string, number int) ([]string, error) {
+	self.changeLock.RLock()
+	defer self.changeLock.RUnlock()
+	if self.status != BUILDED {
+		return nil, ErrNotBuilt
+	}
+	results := make([]string, 0)
+	if len(key) == 0 {
+		return results, nil
+	}
+	bucketNumber := len(self.buckets)
+	validNumber := bucketNumber - len(self.pendingTargetMap)
+	if validNumber == 0 {
+		return nil, ErrEmptyRing
+	}
+	if number <= 0 {
+		number = 1
+	}
+	if number > validNumber {
+		number = validNumber
+	}
+	chosen := make(map[string]bool, number)
+	keyHash := self.KeyHashFunc(key)
+	index := 0
+	for i := 0; len(results) < number && i < bucketNumber*KETAMA_NUMBERS_LENGTH; i++ {
+		index = self.getBucket(keyHash, bucketNumber)
+		target := self.buckets[index]
+		if !self.pendingTargetMap[target] && !chosen[target] {
+			results = append(results, target)
+			chosen[target] = true
+		}
+		keyHash = keyHash*2862933555777941757 + 1
+	}
+	for i := 1; len(results) < number && i <= bucketNumber; i++ {
+		target := self.buckets[(index+i)%bucketNumber]
+		if !self.pendingTargetMap[target] && !chosen[target] {
+			results = append(results, target)
+			chosen[target] = true
+		}
+	}
+	return results, nil
+}
+
+func (self *JumpHashRing) getBucket(keyHash uint64, bucketNumber int) int {
+	if self.Mode == GUAVA {
+		return GetGuavaConsistentHash(keyHash, bucketNumber)
+	}
+	return GetJumpHash(keyHash, bucketNumber)
+}
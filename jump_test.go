@@ -0,0 +1,156 @@
+package chash4go
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestGetGuavaConsistentHash(t *testing.T) {
+	golden100 := [...]int{0, 55, 62, 8, 45, 59, 86, 97, 82, 59, 73, 37, 17, 56, 86, 21, 90, 37, 38, 83}
+	for i, expectedBucket := range golden100 {
+		bucket := GetGuavaConsistentHash(uint64(i), 100)
+		if bucket != expectedBucket {
+			t.Errorf("The bucket of input '%v' should be %v. (but %v) ", i, expectedBucket, bucket)
+			t.FailNow()
+		}
+	}
+	cases := []struct {
+		input          uint64
+		bucketNumber   int
+		expectedBucket int
+	}{
+		{10863919174838991, 11, 6},
+		{2016238256797177309, 11, 3},
+		{1673758223894951030, 11, 5},
+		{2, 100001, 80343},
+		{2201, 100001, 22152},
+		{2202, 100001, 15018},
+	}
+	for _, c := range cases {
+		bucket := GetGuavaConsistentHash(c.input, c.bucketNumber)
+		if bucket != c.expectedBucket {
+			t.Errorf("The bucket of input '%v' (bucketNumber=%v) should be %v. (but %v) ", c.input, c.bucketNumber, c.expectedBucket, bucket)
+			t.FailNow()
+		}
+	}
+}
+
+func TestGetJumpHash(t *testing.T) {
+	for i := 0; i < 1000; i++ {
+		keyHash := GetJumpKeyHash(strconv.Itoa(i))
+		previous := GetJumpHash(keyHash, 1)
+		if previous != 0 {
+			t.Errorf("The bucket of key '%v' should be 0. (but %v) ", i, previous)
+			t.FailNow()
+		}
+		for bucketNumber := 2; bucketNumber <= 50; bucketNumber++ {
+			bucket := GetJumpHash(keyHash, bucketNumber)
+			if bucket != previous && bucket != bucketNumber-1 {
+				t.Errorf("The key '%v' should only move to the new bucket %v. (but %v) ", i, bucketNumber-1, bucket)
+				t.FailNow()
+			}
+			previous = bucket
+		}
+	}
+}
+
+func TestJumpHashRing(t *testing.T) {
+	servers := [...]string{"10.11.156.71:2181", "10.11.5.145:2181", "10.11.5.164:2181", "192.168.106.63:2181", "192.168.106.64:2181"}
+	for _, mode := range []JumpHashMode{LAMPING_VEACH, GUAVA} {
+		var hashRing HashRing = &JumpHashRing{Mode: mode}
+		jhr := hashRing.(*JumpHashRing)
+		err := jhr.Build(0)
+		if err != nil {
+			t.Errorf("Build hash ring Error: %s", err)
+			t.FailNow()
+		}
+		for _, s := range servers {
+			if err := jhr.AddTarget(s); err != nil {
+				t.Errorf("Adding server Error: %s", err)
+				t.FailNow()
+			}
+		}
+		if err := jhr.AddTarget(servers[0]); err == nil {
+			t.Errorf("Adding server '%s' again should be FAILING.", servers[0])
+			t.FailNow()
+		}
+		keyNumber := 10000
+		getTargetsOfKeys := func() map[string]string {
+			result := make(map[string]string, keyNumber)
+			for i := 0; i < keyNumber; i++ {
+				key := "key-" + strconv.Itoa(i)
+				target, err := jhr.GetTarget(key)
+				if err != nil || len(target) == 0 {
+					t.Errorf("Getting target of key '%s' is FAILING. (err=%v)", key, err)
+					t.FailNow()
+				}
+				result[key] = target
+			}
+			return result
+		}
+		before := getTargetsOfKeys()
+		// begin - test about check
+		invalidServer := servers[1]
+		err = jhr.Check(func(target string) bool { return target != invalidServer })
+		if err != nil {
+			t.Errorf("Check Error: %s", err)
+			t.FailNow()
+		}
+		checked := getTargetsOfKeys()
+		for key, target := range checked {
+			if target == invalidServer {
+				t.Errorf("The key '%s' should not be located at invalid target '%s'.", key, target)
+				t.FailNow()
+			}
+			if before[key] != invalidServer && before[key] != target {
+				t.Errorf("The key '%s' should not move from '%s' to '%s'.", key, before[key], target)
+				t.FailNow()
+			}
+		}
+		targets, _ := jhr.GetTargets("chash_test", len(servers))
+		if len(targets) != len(servers)-1 {
+			t.Errorf("The length of targets %v should be %v.", targets, len(servers)-1)
+			t.FailNow()
+		}
+		err = jhr.Check(func(target string) bool { return true })
+		if err != nil {
+			t.Errorf("Check Error: %s", err)
+			t.FailNow()
+		}
+		for key, target := range getTargetsOfKeys() {
+			if before[key] != target {
+				t.Errorf("The target '%s' of key '%s' should be restored to '%s'.", target, key, before[key])
+				t.FailNow()
+			}
+		}
+		// end - test about check
+		// begin - test about remove non-tail target
+		removedServer := servers[2]
+		tailServer := servers[len(servers)-1]
+		if err := jhr.RemoveTarget(removedServer); err != nil {
+			t.Errorf("Removing target '%s' Error: %s", removedServer, err)
+			t.FailNow()
+		}
+		buckets := jhr.GetBuckets()
+		if buckets[2] != tailServer || len(buckets) != len(servers)-1 {
+			t.Errorf("The tail target '%s' should be moved into bucket 2. (buckets=%v)", tailServer, buckets)
+			t.FailNow()
+		}
+		for key, target := range getTargetsOfKeys() {
+			if before[key] != removedServer && before[key] != tailServer && before[key] != target {
+				t.Errorf("The key '%s' should not move from '%s' to '%s'.", key, before[key], target)
+				t.FailNow()
+			}
+		}
+		// end - test about remove non-tail target
+		if err := jhr.RemoveTarget(removedServer); err == nil {
+			t.Errorf("Removing target '%s' again should be FAILING.", removedServer)
+			t.FailNow()
+		}
+		err = jhr.Destroy()
+		if err != nil || jhr.Status() != DESTROYED {
+			t.Errorf("Destroy hash ring is FAILING. (err=%v)", err)
+			t.FailNow()
+		}
+	}
+}
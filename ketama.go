@@ -0,0 +1,57 @@
+package chash4go
+
+import (
+	"math"
+	"sort"
+)
+
+type CompatibilityProfile string
+
+// Compatibility profile
+const (
+	// The profile which reproduces the continuum of libketama: MD5, 'host:port-i'
+	// naming, 160 points per server scaled by weight, 4 points per digest and
+	// the first 32-bit of MD5 as the key hash.
+	LIBKETAMA CompatibilityProfile = "LIBKETAMA"
+)
+
+// The number of digests per server with the average weight in libketama.
+const LIBKETAMA_SHADOW_NUMBER = 40
+
+// Get the shadow (digest) count of server by the same float arithmetic as libketama:
+// 'floorf(pct * 40.0 * (float)numservers)' where 'pct' is a float.
+func GetLibketamaShadowCount(weight uint16, totalWeight uint64, serverNumber int) int {
+	if totalWeight == 0 {
+		return 0
+	}
+	pct := float32(weight) / float32(totalWeight)
+	return int(math.Floor(float64(float32(float64(pct) * float64(LIBKETAMA_SHADOW_NUMBER) * float64(float32(serverNumber))))))
+}
+
+// Recompute the shadow counts of all targets in libketama profile, since
+// they depend on the total weight & the number of targets.
+func (self *SimpleHashRing) rebalanceShadows() {
+	if self.Compatibility != LIBKETAMA {
+		return
+	}
+	totalWeight := uint64(0)
+	targets := make([]string, 0, len(self.weightMap))
+	for target, weight := range self.weightMap {
+		totalWeight += uint64(weight)
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+	shadowCounts := make(map[string]int, len(targets))
+	for _, target := range targets {
+		shadowCount := GetLibketamaShadowCount(self.weightMap[target], totalWeight, len(targets))
+		shadowCounts[target] = shadowCount
+		if shadowCount < self.shadowCountMap[target] {
+			self.resizeShadows(target, shadowCount)
+		}
+	}
+	for _, target := range targets {
+		if shadowCounts[target] > self.shadowCountMap[target] {
+			self.resizeShadows(target, shadowCounts[target])
+		}
+	}
+}
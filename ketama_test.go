@@ -0,0 +1,109 @@
+package chash4go
+
+import (
+	"testing"
+)
+
+func TestGetLibketamaShadowCount(t *testing.T) {
+	cases := []struct {
+		weight              uint16
+		totalWeight         uint64
+		serverNumber        int
+		expectedShadowCount int
+	}{
+		{1, 3, 3, 40},
+		{100, 400, 4, 40},
+		{3, 4, 2, 60},
+		{1, 4, 2, 20},
+		{600, 1000, 3, 72},
+	}
+	for _, c := range cases {
+		shadowCount := GetLibketamaShadowCount(c.weight, c.totalWeight, c.serverNumber)
+		if shadowCount != c.expectedShadowCount {
+			t.Errorf("The shadow count of weight %v (total=%v, servers=%v) should be %v. (but %v) ", c.weight, c.totalWeight, c.serverNumber, c.expectedShadowCount, shadowCount)
+			t.FailNow()
+		}
+	}
+}
+
+// The test vectors are generated by libketama-compatible continuum of libcouchbase.
+func TestSimpleHashRingWithLibketama(t *testing.T) {
+	servers := [...]string{"10.0.0.195:12000", "localhost:12002", "localhost:12004", "localhost:12006"}
+	shr := SimpleHashRing{Compatibility: LIBKETAMA}
+	err := shr.Build(0)
+	if err != nil {
+		t.Errorf("Build hash ring Error: %s", err)
+		t.FailNow()
+	}
+	for _, s := range servers {
+		if err := shr.AddTarget(s); err != nil {
+			t.Errorf("Adding server Error: %s", err)
+			t.FailNow()
+		}
+	}
+	expectedLength := 160 * len(servers)
+	if shr.nodeRing.Len() != expectedLength {
+		t.Errorf("The length '%d' of continuum should be %d.", shr.nodeRing.Len(), expectedLength)
+		t.FailNow()
+	}
+	vectors := []struct {
+		key           string
+		expectedHash  uint64
+		expectedIndex int
+	}{
+		{"Key_0", 1026020100, 0},
+		{"Key_1", 3873048688, 3},
+		{"Key_2", 2403924765, 3},
+		{"Key_3", 2008332683, 2},
+		{"Key_4", 1573343827, 2},
+		{"Key_5", 1871385817, 1},
+		{"Key_6", 1628642608, 1},
+		{"Key_7", 664051479, 1},
+		{"Key_8", 3667930227, 2},
+		{"Key_9", 3227600046, 3},
+		{"Key_10", 2719205511, 2},
+		{"Key_42", 3482604535, 1},
+		{"Key_99", 1177271600, 3},
+		{"Key_100", 2592843775, 2},
+		{"Key_256", 2817526639, 2},
+		{"Key_511", 451958785, 1},
+		{"Key_512", 1612286462, 1},
+		{"Key_777", 1844316238, 3},
+		{"Key_1000", 282456685, 3},
+		{"Key_1023", 1462001454, 2},
+	}
+	for _, v := range vectors {
+		keyHash := shr.getKeyHash(v.key)
+		if keyHash != v.expectedHash {
+			t.Errorf("The hash of key '%s' should be %v. (but %v) ", v.key, v.expectedHash, keyHash)
+			t.FailNow()
+		}
+		target, _ := shr.GetTarget(v.key)
+		if target != servers[v.expectedIndex] {
+			t.Errorf("The target '%s' of key '%s' should be '%s'.", target, v.key, servers[v.expectedIndex])
+			t.FailNow()
+		}
+	}
+	// begin - test about weight
+	err = shr.SetWeight(servers[0], 300)
+	if err != nil {
+		t.Errorf("Setting weight is FAILING. (err=%v)", err)
+		t.FailNow()
+	}
+	expectedShadowCounts := map[string]int{servers[0]: 80, servers[1]: 26, servers[2]: 26, servers[3]: 26}
+	for target, expectedShadowCount := range expectedShadowCounts {
+		if shr.shadowCountMap[target] != expectedShadowCount {
+			t.Errorf("The shadow count '%d' of target '%s' should be %d.", shr.shadowCountMap[target], target, expectedShadowCount)
+			t.FailNow()
+		}
+	}
+	shr.SetWeight(servers[0], DEFAULT_WEIGHT)
+	for _, v := range vectors {
+		target, _ := shr.GetTarget(v.key)
+		if target != servers[v.expectedIndex] {
+			t.Errorf("The target '%s' of key '%s' should be restored to '%s'.", target, v.key, servers[v.expectedIndex])
+			t.FailNow()
+		}
+	}
+	// end - test about weight
+}
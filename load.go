@@ -0,0 +1,112 @@
+package chash4go
+
+import (
+	"math"
+	"sync"
+)
+
+// The default balance factor of consistent hashing with bounded loads.
+const DEFAULT_BALANCE_FACTOR float64 = 1.25
+
+/*
+ * The in-flight loads of targets for the consistent hashing with
+ * bounded loads (Mirrokni, Thorup & Zadimoghaddam).
+ */
+type boundedLoadBalancer struct {
+	balanceFactor float64
+	loadMap       map[string]int64
+	totalLoad     int64
+	lock          sync.Mutex
+}
+
+func (self *boundedLoadBalancer) reset() {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	self.loadMap = make(map[string]int64)
+	self.totalLoad = 0
+}
+
+// Get the capacity of each target, i.e. ceil(c * average load) with the new load included.
+// It should be called with the lock held.
+func (self *boundedLoadBalancer) getCapacity(targetNumber int) int64 {
+	balanceFactor := self.balanceFactor
+	if balanceFactor < 1 {
+		balanceFactor = DEFAULT_BALANCE_FACTOR
+	}
+	averageLoad := float64(self.totalLoad+1) / float64(targetNumber)
+	return int64(math.Ceil(balanceFactor * averageLoad))
+}
+
+// It should be called with the lock held.
+func (self *boundedLoadBalancer) acquire(target string) func() {
+	if self.loadMap == nil {
+		self.loadMap = make(map[string]int64)
+	}
+	self.loadMap[target]++
+	self.totalLoad++
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			self.lock.Lock()
+			defer self.lock.Unlock()
+			if self.loadMap[target] > 0 {
+				self.loadMap[target]--
+				self.totalLoad--
+			}
+			if self.loadMap[target] == 0 {
+				delete(self.loadMap, target)
+			}
+		})
+	}
+}
+
+// Set the balance factor 'c' of the consistent hashing with bounded loads.
+// The capacity of each target is ceil(c * average load), and 'c' should not be less than 1.
+func (self *SimpleHashRing) SetBalanceFactor(balanceFactor float64) error {
+	if balanceFactor < 1 {
+		return &ArgumentError{"balanceFactor", balanceFactor, "It should not be less than 1."}
+	}
+	self.loadBalancer.lock.Lock()
+	defer self.loadBalancer.lock.Unlock()
+	self.loadBalancer.balanceFactor = balanceFactor
+	return nil
+}
+
+// Acquire the target of key by the consistent hashing with bounded loads.
+// The key walks clockwise on the node ring past the saturated targets.
+// The release function should be called when the load on target is done.
+func (self *SimpleHashRing) Acquire(key string) (string, func(), error) {
+	snapshot := self.Snapshot()
+	if snapshot == nil {
+		return "", func() {}, ErrNotBuilt
+	}
+	if len(key) == 0 {
+		return "", func() {}, nil
+	}
+	if len(snapshot.targets) == 0 {
+		return "", func() {}, ErrEmptyRing
+	}
+	self.loadBalancer.lock.Lock()
+	defer self.loadBalancer.lock.Unlock()
+	capacity := self.loadBalancer.getCapacity(len(snapshot.targets))
+	currentKeyHash := snapshot.getKeyHash(key)
+	for i := 0; i < snapshot.nodeRing.Len(); i++ {
+		matchedNode := snapshot.nodeRing.Next(currentKeyHash)
+		if self.loadBalancer.loadMap[matchedNode.Target] < capacity {
+			return matchedNode.Target, self.loadBalancer.acquire(matchedNode.Target), nil
+		}
+		currentKeyHash = matchedNode.Key + 1
+	}
+	return "", func() {}, ErrSaturated
+}
+
+// Get the in-flight loads of targets which are acquired.
+func (self *SimpleHashRing) GetLoads() map[string]int64 {
+	self.loadBalancer.lock.Lock()
+	defer self.loadBalancer.lock.Unlock()
+	loads := make(map[string]int64, len(self.loadBalancer.loadMap))
+	for target, load := range self.loadBalancer.loadMap {
+		loads[target] = load
+	}
+	return loads
+}
@@ -0,0 +1,79 @@
+package chash4go
+
+import (
+	"math"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestSimpleHashRingAcquire(t *testing.T) {
+	servers := [...]string{"10.11.156.71:2181", "10.11.5.145:2181", "10.11.5.164:2181", "192.168.106.63:2181", "192.168.106.64:2181"}
+	shr := SimpleHashRing{}
+	shr.Build(100)
+	for _, s := range servers {
+		shr.AddTarget(s)
+	}
+	if err := shr.SetBalanceFactor(0.5); err == nil {
+		t.Errorf("Setting balance factor less than 1 should be FAILING.")
+		t.FailNow()
+	}
+	balanceFactor := 1.25
+	if err := shr.SetBalanceFactor(balanceFactor); err != nil {
+		t.Errorf("Setting balance factor Error: %s", err)
+		t.FailNow()
+	}
+	// begin - test about hot key
+	hotKey := "chash_test"
+	expectedTarget, _ := shr.GetTarget(hotKey)
+	acquireNumber := 100
+	releaseFuncs := make([]func(), 0, acquireNumber)
+	for i := 0; i < acquireNumber; i++ {
+		target, release, err := shr.Acquire(hotKey)
+		if err != nil || len(target) == 0 {
+			t.Errorf("Acquiring target of key '%s' is FAILING. (err=%v)", hotKey, err)
+			t.FailNow()
+		}
+		if i == 0 && target != expectedTarget {
+			t.Errorf("The first target '%s' of key '%s' should be '%s'.", target, hotKey, expectedTarget)
+			t.FailNow()
+		}
+		releaseFuncs = append(releaseFuncs, release)
+	}
+	loads := shr.GetLoads()
+	t.Logf("The loads of targets: %v", loads)
+	capacity := int64(math.Ceil(balanceFactor * float64(acquireNumber) / float64(len(servers))))
+	for target, load := range loads {
+		if load > capacity {
+			t.Errorf("The load '%d' of target '%s' should not be greater than %d.", load, target, capacity)
+			t.FailNow()
+		}
+	}
+	for _, release := range releaseFuncs {
+		release()
+		release()
+	}
+	if len(shr.GetLoads()) != 0 {
+		t.Errorf("The loads %v should be empty after releasing.", shr.GetLoads())
+		t.FailNow()
+	}
+	// end - test about hot key
+	// begin - test about concurrency
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				_, release, _ := shr.Acquire("key-" + strconv.Itoa(n*j))
+				release()
+			}
+		}(i)
+	}
+	wg.Wait()
+	if len(shr.GetLoads()) != 0 {
+		t.Errorf("The loads %v should be empty after releasing.", shr.GetLoads())
+		t.FailNow()
+	}
+	// end - test about concurrency
+}
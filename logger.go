@@ -0,0 +1,82 @@
+package chash4go
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"time"
+)
+
+type Logger interface {
+	Debugf(format string, v ...interface{})
+	Infof(format string, v ...interface{})
+	Warnf(format string, v ...interface{})
+	Errorf(format string, v ...interface{})
+}
+
+// The logger which discards all logs. It is the default one.
+type NopLogger struct{}
+
+func (self NopLogger) Debugf(format string, v ...interface{}) {}
+
+func (self NopLogger) Infof(format string, v ...interface{}) {}
+
+func (self NopLogger) Warnf(format string, v ...interface{}) {}
+
+func (self NopLogger) Errorf(format string, v ...interface{}) {}
+
+// The logger which passes the logs to a 'slog.Handler' as records.
+type SlogLogger struct {
+	handler slog.Handler
+}
+
+func NewSlogLogger(handler slog.Handler) *SlogLogger {
+	return &SlogLogger{handler: handler}
+}
+
+func (self *SlogLogger) Debugf(format string, v ...interface{}) {
+	self.log(slog.LevelDebug, format, v...)
+}
+
+func (self *SlogLogger) Infof(format string, v ...interface{}) {
+	self.log(slog.LevelInfo, format, v...)
+}
+
+func (self *SlogLogger) Warnf(format string, v ...interface{}) {
+	self.log(slog.LevelWarn, format, v...)
+}
+
+func (self *SlogLogger) Errorf(format string, v ...interface{}) {
+	self.log(slog.LevelError, format, v...)
+}
+
+func (self *SlogLogger) log(level slog.Level, format string, v ...interface{}) {
+	ctx := context.Background()
+	if !self.handler.Enabled(ctx, level) {
+		return
+	}
+	// Skip the frames of 'runtime.Callers', 'log' & the level method.
+	var pcs [1]uintptr
+	runtime.Callers(3, pcs[:])
+	record := slog.NewRecord(time.Now(), level, fmt.Sprintf(format, v...), pcs[0])
+	self.handler.Handle(ctx, record)
+}
+
+// The logger holder which is shared by the hash rings.
+// The package logger is used if the logger of ring is not set.
+type ringLogger struct {
+	logger Logger
+}
+
+// Set the logger of ring. It should be called before the ring is in use.
+func (self *ringLogger) SetLogger(logger Logger) {
+	self.logger = logger
+}
+
+func (self *ringLogger) getLogger() Logger {
+	if self.logger == nil {
+		return logger
+	}
+	return self.logger
+}
@@ -0,0 +1,84 @@
+package chash4go
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestSlogLogger(t *testing.T) {
+	var buffer bytes.Buffer
+	handler := slog.NewTextHandler(&buffer, &slog.HandlerOptions{Level: slog.LevelInfo})
+	slogLogger := NewSlogLogger(handler)
+	slogLogger.Debugf("The debug log of '%s'.", "chash_test")
+	if buffer.Len() != 0 {
+		t.Errorf("The debug log should be discarded. (but %s)", buffer.String())
+		t.FailNow()
+	}
+	slogLogger.Warnf("The warning log of '%s'.", "chash_test")
+	content := buffer.String()
+	if !strings.Contains(content, "level=WARN") || !strings.Contains(content, "The warning log of 'chash_test'.") {
+		t.Errorf("The content '%s' of log is unexpected.", content)
+		t.FailNow()
+	}
+}
+
+func TestHashRingWithLogger(t *testing.T) {
+	var buffer bytes.Buffer
+	slogLogger := NewSlogLogger(slog.NewTextHandler(&buffer, nil))
+	shr, err := NewSimpleHashRing(WithLogger(slogLogger))
+	if err != nil {
+		t.Errorf("Creating hash ring Error: %s", err)
+		t.FailNow()
+	}
+	jhr := &JumpHashRing{}
+	jhr.SetLogger(slogLogger)
+	jhr.Build(0)
+	for _, hashRing := range []HashRing{shr, jhr} {
+		buffer.Reset()
+		hashRing.AddTarget("10.11.5.145:2181")
+		hashRing.Check(func(target string) bool { return false })
+		content := buffer.String()
+		if !strings.Contains(content, "Removing invalid target '10.11.5.145:2181'...") {
+			t.Errorf("The content '%s' of log of %T is unexpected.", content, hashRing)
+			t.FailNow()
+		}
+	}
+	buffer.Reset()
+	defaultRing := &RendezvousRing{}
+	defaultRing.Build(0)
+	defaultRing.AddTarget("10.11.5.145:2181")
+	defaultRing.Check(func(target string) bool { return false })
+	if buffer.Len() != 0 {
+		t.Errorf("The logs of hash ring without logger should be discarded.")
+		t.FailNow()
+	}
+}
+
+type panicStopChecker struct {
+	CycleChecker
+}
+
+func (self *panicStopChecker) Stop() bool {
+	panic("stop checker")
+}
+
+func TestHashRingWithLoggerForStopCheck(t *testing.T) {
+	var buffer bytes.Buffer
+	checker := &panicStopChecker{}
+	defer checker.CycleChecker.Stop()
+	shr, _ := NewSimpleHashRing(WithLogger(NewSlogLogger(slog.NewTextHandler(&buffer, nil))), WithChecker(checker))
+	shr.AddTarget("10.11.5.145:2181")
+	shr.StartCheck(func(target string) bool { return true }, 1)
+	var panicError *PanicError
+	if _, err := shr.StopCheck(); !errors.As(err, &panicError) {
+		t.Errorf("Stopping the checker should be a panic error. (but %v)", err)
+		t.FailNow()
+	}
+	if content := buffer.String(); !strings.Contains(content, "stop checker") {
+		t.Errorf("The panic of stopping checker should be logged by the ring logger. (content=%s)", content)
+		t.FailNow()
+	}
+}
@@ -0,0 +1,302 @@
+package chash4go
+
+import (
+	"encoding/binary"
+	"math/big"
+	"sort"
+	"sync"
+)
+
+// The default size of maglev lookup table. It should be a prime number.
+const DEFAULT_MAGLEV_TABLE_SIZE uint64 = 65537
+
+type maglevTarget struct {
+	offset uint64
+	skip   uint64
+}
+
+/*
+ * A hash ring based on the lookup table of Google Maglev. Each target
+ * fills the table by its own permutation in turn, so a lookup is only
+ * one access to the table. The offsets & skips of the permutations are
+ * cached, but the table is not updated incrementally: the whole table is
+ * rebuilt from them whenever the valid targets are changed, including the
+ * ejections & re-admissions of checks, which costs O(TableSize).
+ */
+type MaglevRing struct {
+	TableSize        uint64
+	targetMap        map[string]maglevTarget
+	pendingTargetMap map[string]maglevTarget
+	table            []int
+	tableTargets     []string
+	changeLock       sync.RWMutex
+	status           HashRingStatus
+	ringLogger
+	ringChecker
+}
+
+var _ HashRing = (*MaglevRing)(nil)
+
+func (self *MaglevRing) initialize() {
+	self.targetMap = make(map[string]maglevTarget)
+	self.pendingTargetMap = make(map[string]maglevTarget)
+	self.table = nil
+	self.tableTargets = nil
+	if self.TableSize == 0 {
+		self.TableSize = DEFAULT_MAGLEV_TABLE_SIZE
+	}
+	self.status = INITIALIZED
+}
+
+// The shadow number is ignored. The table size should be a prime number.
+func (self *MaglevRing) Build(shadowNumber uint16) error {
+	self.changeLock.Lock()
+	defer self.changeLock.Unlock()
+	switch self.status {
+	case "", UNINITIALIZED, DESTROYED:
+		if self.TableSize != 0 && !new(big.Int).SetUint64(self.TableSize).ProbablyPrime(0) {
+			return &ArgumentError{"TableSize", self.TableSize, "It should be a prime number."}
+		}
+		self.initialize()
+		fallthrough
+	case INITIALIZED:
+		self.status = BUILDED
+	default:
+		return ErrAlreadyBuilt
+	}
+	return nil
+}
+
+func (self *MaglevRing) Destroy() error {
+	self.StopCheck()
+	self.changeLock.Lock()
+	defer self.changeLock.Unlock()
+	switch self.status {
+	case INITIALIZED, BUILDED:
+		self.targetMap = nil
+		self.pendingTargetMap = nil
+		self.table = nil
+		self.tableTargets = nil
+		self.status = DESTROYED
+	default:
+		return ErrNotBuilt
+	}
+	return nil
+}
+
+func (self *MaglevRing) Status() HashRingStatus {
+	self.changeLock.RLock()
+	defer self.changeLock.RUnlock()
+	if len(self.status) == 0 {
+		return UNINITIALIZED
+	}
+	return self.status
+}
+
+// Check the targets and rebuild the table once if some targets are ejected or re-admitted.
+func (self *MaglevRing) Check(nodeCheckFunc NodeCheckFunc) (err error) {
+	defer recoverError("check maglev ring", &err, self.getLogger())
+	self.changeLock.RLock()
+	if self.status != BUILDED {
+		self.changeLock.RUnlock()
+		return ErrNotBuilt
+	}
+	targets := make([]string, 0, len(self.targetMap)+len(self.pendingTargetMap))
+	for target := range self.targetMap {
+		targets = append(targets, target)
+	}
+	for target := range self.pendingTargetMap {
+		targets = append(targets, target)
+	}
+	self.changeLock.RUnlock()
+	validMap := make(map[string]bool, len(targets))
+	for _, target := range targets {
+		validMap[target] = nodeCheckFunc(target)
+	}
+	self.changeLock.Lock()
+	defer self.changeLock.Unlock()
+	changed := false
+	for target, valid := range validMap {
+		if mt, exists := self.targetMap[target]; exists && !valid {
+			self.getLogger().Infof("Removing invalid target '%s'...", target)
+			self.pendingTargetMap[target] = mt
+			delete(self.targetMap, target)
+			changed = true
+		} else if mt, exists := self.pendingTargetMap[target]; exists && valid {
+			self.getLogger().Infof("Adding valid target '%s'...", target)
+			self.targetMap[target] = mt
+			delete(self.pendingTargetMap, target)
+			changed = true
+		}
+	}
+	if changed {
+		self.rebuildTable()
+	}
+	return nil
+}
+
+func (self *MaglevRing) StartCheck(nodeCheckFunc NodeCheckFunc, intervalSeconds uint16) (bool, error) {
+	if self.Status() != BUILDED {
+		return false, ErrNotBuilt
+	}
+	checkFunc := func() {
+		err := self.Check(nodeCheckFunc)
+		if err != nil {
+			self.getLogger().Errorf("Maglev ring checking is FAILING: %s", err)
+		}
+	}
+	return self.startChecker(checkFunc, intervalSeconds, self.getLogger()), nil
+}
+
+func (self *MaglevRing) AddTarget(target string) error {
+	self.changeLock.Lock()
+	defer self.changeLock.Unlock()
+	if self.status != BUILDED {
+		return ErrNotBuilt
+	}
+	_, active := self.targetMap[target]
+	_, pending := self.pendingTargetMap[target]
+	if active || pending {
+		return &TargetError{"add target", target, ErrTargetExists}
+	}
+	hashBytes := GetHashBytes(target)
+	self.targetMap[target] = maglevTarget{
+		offset: binary.LittleEndian.Uint64(hashBytes[:8]) % self.TableSize,
+		skip:   binary.LittleEndian.Uint64(hashBytes[8:16])%(self.TableSize-1) + 1,
+	}
+	self.rebuildTable()
+	return nil
+}
+
+func (self *MaglevRing) RemoveTarget(target string) error {
+	self.changeLock.Lock()
+	defer self.changeLock.Unlock()
+	if self.status != BUILDED {
+		return ErrNotBuilt
+	}
+	if _, exists := self.targetMap[target]; exists {
+		delete(self.targetMap, target)
+		self.rebuildTable()
+		return nil
+	}
+	if _, exists := self.pendingTargetMap[target]; exists {
+		delete(self.pendingTargetMap, target)
+		return nil
+	}
+	return &TargetError{"remove target", target, ErrUnknownTarget}
+}
+
+func (self *MaglevRing) ContainsTarget(target string) bool {
+	self.changeLock.RLock()
+	defer self.changeLock.RUnlock()
+	_, active := self.targetMap[target]
+	_, pending := self.pendingTargetMap[target]
+	return active || pending
+}
+
+func (self *MaglevRing) GetActiveTargets() []string {
+	self.changeLock.RLock()
+	defer self.changeLock.RUnlock()
+	targets := make([]string, 0, len(self.targetMap))
+	for target := range self.targetMap {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+	return targets
+}
+
+func (self *MaglevRing) GetPendingTargets() []string {
+	self.changeLock.RLock()
+	defer self.changeLock.RUnlock()
+	targets := make([]string, 0, len(self.pendingTargetMap))
+	for target := range self.pendingTargetMap {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+	return targets
+}
+
+func (self *MaglevRing) GetTarget(key string) (string, error) {
+	results, err := self.GetTargets(key, 1)
+	if err != nil {
+		return "", err
+	}
+	if len(results) == 0 {
+		return "", nil
+	}
+	return results[0], nil
+}
+
+// Get the targets by walking the table from the entry of key.
+func (self *MaglevRing) GetTargets(key string, number int) ([]string, error) {
+	self.changeLock.RLock()
+	defer self.changeLock.RUnlock()
+	if self.status != BUILDED {
+		return nil, ErrNotBuilt
+	}
+	results := make([]string, 0)
+	if len(key) == 0 {
+		return results, nil
+	}
+	if len(self.table) == 0 {
+		return nil, ErrEmptyRing
+	}
+	if number <= 0 {
+		number = 1
+	}
+	targetNumber := len(self.tableTargets)
+	if number > targetNumber {
+		number = targetNumber
+	}
+	tableSize := uint64(len(self.table))
+	entry := GetHash64(key) % tableSize
+	chosen := make(map[int]bool, number)
+	for i := uint64(0); len(results) < number && i < tableSize; i++ {
+		index := self.table[(entry+i)%tableSize]
+		if !chosen[index] {
+			results = append(results, self.tableTargets[index])
+			chosen[index] = true
+		}
+	}
+	return results, nil
+}
+
+// Populate the whole table by the permutations of valid targets.
+func (self *MaglevRing) rebuildTable() {
+	targetNumber := len(self.targetMap)
+	if targetNumber == 0 {
+		self.table = nil
+		self.tableTargets = nil
+		return
+	}
+	targets := make([]string, 0, targetNumber)
+	for target := range self.targetMap {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+	tableSize := self.TableSize
+	positions := make([]uint64, targetNumber)
+	skips := make([]uint64, targetNumber)
+	for i, target := range targets {
+		mt := self.targetMap[target]
+		positions[i] = mt.offset
+		skips[i] = mt.skip
+	}
+	table := make([]int, tableSize)
+	for i := range table {
+		table[i] = -1
+	}
+	filled := uint64(0)
+	for filled < tableSize {
+		for i := 0; i < targetNumber && filled < tableSize; i++ {
+			for table[positions[i]] >= 0 {
+				positions[i] = (positions[i] + skips[i]) % tableSize
+			}
+			table[positions[i]] = i
+			positions[i] = (positions[i] + skips[i]) % tableSize
+			filled++
+		}
+	}
+	self.table = table
+	self.tableTargets = targets
+}
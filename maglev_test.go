@@ -0,0 +1,99 @@
+package chash4go
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestMaglevRing(t *testing.T) {
+	servers := [...]string{"10.11.156.71:2181", "10.11.5.145:2181", "10.11.5.164:2181", "192.168.106.63:2181", "192.168.106.64:2181"}
+	if err := (&MaglevRing{TableSize: 65536}).Build(0); err == nil {
+		t.Errorf("Building maglev ring with non-prime table size should be FAILING.")
+		t.FailNow()
+	}
+	var hashRing HashRing = &MaglevRing{TableSize: 5003}
+	mr := hashRing.(*MaglevRing)
+	err := mr.Build(0)
+	if err != nil {
+		t.Errorf("Build hash ring Error: %s", err)
+		t.FailNow()
+	}
+	for _, s := range servers {
+		if err := mr.AddTarget(s); err != nil {
+			t.Errorf("Adding server Error: %s", err)
+			t.FailNow()
+		}
+	}
+	// begin - test about balance of table
+	entryCounts := make(map[int]int)
+	for _, index := range mr.table {
+		entryCounts[index]++
+	}
+	expectedCount := len(mr.table) / len(servers)
+	for index, count := range entryCounts {
+		if count < expectedCount-1 || count > expectedCount+1 {
+			t.Errorf("The entry count '%d' of target '%s' should be near to %d.", count, mr.tableTargets[index], expectedCount)
+			t.FailNow()
+		}
+	}
+	// end - test about balance of table
+	keyNumber := 10000
+	getTargetsOfKeys := func() map[string]string {
+		result := make(map[string]string, keyNumber)
+		for i := 0; i < keyNumber; i++ {
+			key := "key-" + strconv.Itoa(i)
+			target, err := mr.GetTarget(key)
+			if err != nil || len(target) == 0 {
+				t.Errorf("Getting target of key '%s' is FAILING. (err=%v)", key, err)
+				t.FailNow()
+			}
+			result[key] = target
+		}
+		return result
+	}
+	before := getTargetsOfKeys()
+	// begin - test about check
+	invalidServer := servers[2]
+	mr.Check(func(target string) bool { return target != invalidServer })
+	moved := 0
+	for key, target := range getTargetsOfKeys() {
+		if target == invalidServer {
+			t.Errorf("The key '%s' should not be located at invalid target '%s'.", key, target)
+			t.FailNow()
+		}
+		if before[key] != invalidServer && before[key] != target {
+			moved++
+		}
+	}
+	t.Logf("The number of moved keys which are not located at invalid target: %d", moved)
+	if moved > keyNumber/20 {
+		t.Errorf("Too many keys (%d) are moved.", moved)
+		t.FailNow()
+	}
+	mr.Check(func(target string) bool { return true })
+	for key, target := range getTargetsOfKeys() {
+		if before[key] != target {
+			t.Errorf("The target '%s' of key '%s' should be restored to '%s'.", target, key, before[key])
+			t.FailNow()
+		}
+	}
+	// end - test about check
+	targets, _ := mr.GetTargets("chash_test", len(servers))
+	if len(targets) != len(servers) {
+		t.Errorf("The length of targets %v should be %v.", targets, len(servers))
+		t.FailNow()
+	}
+	if err := mr.RemoveTarget(targets[0]); err != nil {
+		t.Errorf("Removing target '%s' Error: %s", targets[0], err)
+		t.FailNow()
+	}
+	target, _ := mr.GetTarget("chash_test")
+	if target == targets[0] {
+		t.Errorf("The removed target '%s' should not be got.", target)
+		t.FailNow()
+	}
+	if err := mr.Destroy(); err != nil || mr.Status() != DESTROYED {
+		t.Errorf("Destroy hash ring is FAILING. (err=%v)", err)
+		t.FailNow()
+	}
+}
@@ -0,0 +1,266 @@
+package chash4go
+
+import (
+	"sort"
+	"sync"
+)
+
+// The default number of probes for each key in multi-probe ring.
+const DEFAULT_PROBE_NUMBER uint16 = 21
+
+/*
+ * A hash ring based on the multi-probe consistent hashing (Appleton &
+ * O'Reilly). Each target has only one node on the ring, and each key is
+ * hashed for several times. The target whose node is the closest to one
+ * of these probes (clockwise) is chosen.
+ */
+type MultiProbeRing struct {
+	ProbeNumber      uint16
+	nodeRing         *NodeRing
+	targetMap        map[string]uint64
+	pendingTargetMap map[string]uint64
+	changeLock       sync.RWMutex
+	status           HashRingStatus
+	ringLogger
+	ringChecker
+}
+
+var _ HashRing = (*MultiProbeRing)(nil)
+
+func (self *MultiProbeRing) initialize() {
+	self.nodeRing = NewNodeRing()
+	self.targetMap = make(map[string]uint64)
+	self.pendingTargetMap = make(map[string]uint64)
+	if self.ProbeNumber == 0 {
+		self.ProbeNumber = DEFAULT_PROBE_NUMBER
+	}
+	self.status = INITIALIZED
+}
+
+// The shadow number is ignored since each target has only one node in multi-probe ring.
+func (self *MultiProbeRing) Build(shadowNumber uint16) error {
+	self.changeLock.Lock()
+	defer self.changeLock.Unlock()
+	switch self.status {
+	case "", UNINITIALIZED, DESTROYED:
+		self.initialize()
+		fallthrough
+	case INITIALIZED:
+		self.status = BUILDED
+	default:
+		return ErrAlreadyBuilt
+	}
+	return nil
+}
+
+func (self *MultiProbeRing) Destroy() error {
+	self.StopCheck()
+	self.changeLock.Lock()
+	defer self.changeLock.Unlock()
+	switch self.status {
+	case INITIALIZED, BUILDED:
+		self.nodeRing = nil
+		self.targetMap = nil
+		self.pendingTargetMap = nil
+		self.status = DESTROYED
+	default:
+		return ErrNotBuilt
+	}
+	return nil
+}
+
+func (self *MultiProbeRing) Status() HashRingStatus {
+	self.changeLock.RLock()
+	defer self.changeLock.RUnlock()
+	if len(self.status) == 0 {
+		return UNINITIALIZED
+	}
+	return self.status
+}
+
+func (self *MultiProbeRing) Check(nodeCheckFunc NodeCheckFunc) (err error) {
+	defer recoverError("check multi-probe ring", &err, self.getLogger())
+	self.changeLock.RLock()
+	if self.status != BUILDED {
+		self.changeLock.RUnlock()
+		return ErrNotBuilt
+	}
+	targets := make([]string, 0, len(self.targetMap)+len(self.pendingTargetMap))
+	for target := range self.targetMap {
+		targets = append(targets, target)
+	}
+	for target := range self.pendingTargetMap {
+		targets = append(targets, target)
+	}
+	self.changeLock.RUnlock()
+	for _, target := range targets {
+		valid := nodeCheckFunc(target)
+		self.changeLock.Lock()
+		if nodeKey, exists := self.targetMap[target]; exists && !valid {
+			self.getLogger().Infof("Removing invalid target '%s'...", target)
+			if self.nodeRing.Remove(nodeKey) {
+				self.pendingTargetMap[target] = nodeKey
+				delete(self.targetMap, target)
+			}
+		} else if nodeKey, exists := self.pendingTargetMap[target]; exists && valid {
+			self.getLogger().Infof("Adding valid target '%s'...", target)
+			if _, done := self.nodeRing.Add(Node{nodeKey, target}); done {
+				self.targetMap[target] = nodeKey
+				delete(self.pendingTargetMap, target)
+			}
+		}
+		self.changeLock.Unlock()
+	}
+	return nil
+}
+
+func (self *MultiProbeRing) StartCheck(nodeCheckFunc NodeCheckFunc, intervalSeconds uint16) (bool, error) {
+	if self.Status() != BUILDED {
+		return false, ErrNotBuilt
+	}
+	checkFunc := func() {
+		err := self.Check(nodeCheckFunc)
+		if err != nil {
+			self.getLogger().Errorf("Multi-probe ring checking is FAILING: %s", err)
+		}
+	}
+	return self.startChecker(checkFunc, intervalSeconds, self.getLogger()), nil
+}
+
+func (self *MultiProbeRing) AddTarget(target string) error {
+	self.changeLock.Lock()
+	defer self.changeLock.Unlock()
+	if self.status != BUILDED {
+		return ErrNotBuilt
+	}
+	_, active := self.targetMap[target]
+	_, pending := self.pendingTargetMap[target]
+	if active || pending {
+		return &TargetError{"add target", target, ErrTargetExists}
+	}
+	nodeKey := GetKetamaNumbers(target)[0]
+	if _, done := self.nodeRing.Add(Node{nodeKey, target}); !done {
+		return &TargetError{"add target", target, ErrKeyCollision}
+	}
+	self.targetMap[target] = nodeKey
+	return nil
+}
+
+func (self *MultiProbeRing) RemoveTarget(target string) error {
+	self.changeLock.Lock()
+	defer self.changeLock.Unlock()
+	if self.status != BUILDED {
+		return ErrNotBuilt
+	}
+	if nodeKey, exists := self.targetMap[target]; exists {
+		self.nodeRing.Remove(nodeKey)
+		delete(self.targetMap, target)
+		return nil
+	}
+	if _, exists := self.pendingTargetMap[target]; exists {
+		delete(self.pendingTargetMap, target)
+		return nil
+	}
+	return &TargetError{"remove target", target, ErrUnknownTarget}
+}
+
+func (self *MultiProbeRing) ContainsTarget(target string) bool {
+	self.changeLock.RLock()
+	defer self.changeLock.RUnlock()
+	_, active := self.targetMap[target]
+	_, pending := self.pendingTargetMap[target]
+	return active || pending
+}
+
+func (self *MultiProbeRing) GetActiveTargets() []string {
+	self.changeLock.RLock()
+	defer self.changeLock.RUnlock()
+	targets := make([]string, 0, len(self.targetMap))
+	for target := range self.targetMap {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+	return targets
+}
+
+func (self *MultiProbeRing) GetPendingTargets() []string {
+	self.changeLock.RLock()
+	defer self.changeLock.RUnlock()
+	targets := make([]string, 0, len(self.pendingTargetMap))
+	for target := range self.pendingTargetMap {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+	return targets
+}
+
+func (self *MultiProbeRing) GetTarget(key string) (string, error) {
+	results, err := self.GetTargets(key, 1)
+	if err != nil {
+		return "", err
+	}
+	if len(results) == 0 {
+		return "", nil
+	}
+	return results[0], nil
+}
+
+// Get the targets one by one. Each of them is the closest one to the
+// probes of key except the chosen targets.
+func (self *MultiProbeRing) GetTargets(key string, number int) ([]string, error) {
+	self.changeLock.RLock()
+	defer self.changeLock.RUnlock()
+	if self.status != BUILDED {
+		return nil, ErrNotBuilt
+	}
+	results := make([]string, 0)
+	if len(key) == 0 {
+		return results, nil
+	}
+	targetNumber := len(self.targetMap)
+	if targetNumber == 0 {
+		return nil, ErrEmptyRing
+	}
+	if number <= 0 {
+		number = 1
+	}
+	if number > targetNumber {
+		number = targetNumber
+	}
+	probes := self.getProbes(key)
+	chosen := make(map[string]bool, number)
+	for len(results) < number {
+		var closestTarget string
+		closestDistance := uint64(1) << 32
+		for _, probe := range probes {
+			currentKeyHash := probe
+			for i := 0; i < self.nodeRing.Len(); i++ {
+				matchedNode := self.nodeRing.Next(currentKeyHash)
+				if !chosen[matchedNode.Target] {
+					distance := uint64(uint32(matchedNode.Key) - uint32(probe))
+					if distance < closestDistance {
+						closestDistance = distance
+						closestTarget = matchedNode.Target
+					}
+					break
+				}
+				currentKeyHash = matchedNode.Key + 1
+			}
+		}
+		results = append(results, closestTarget)
+		chosen[closestTarget] = true
+	}
+	return results, nil
+}
+
+// Get the probes of key by double hashing in the 32-bit node space.
+func (self *MultiProbeRing) getProbes(key string) []uint64 {
+	keyHash := GetHash64(key)
+	h1 := uint32(keyHash)
+	h2 := uint32(keyHash>>32) | 1
+	probes := make([]uint64, self.ProbeNumber)
+	for i := range probes {
+		probes[i] = uint64(h1 + uint32(i)*h2)
+	}
+	return probes
+}
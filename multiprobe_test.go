@@ -0,0 +1,90 @@
+package chash4go
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestMultiProbeRing(t *testing.T) {
+	var hashRing HashRing = &MultiProbeRing{}
+	mpr := hashRing.(*MultiProbeRing)
+	err := mpr.Build(0)
+	if err != nil {
+		t.Errorf("Build hash ring Error: %s", err)
+		t.FailNow()
+	}
+	servers := make([]string, 10)
+	for i := range servers {
+		servers[i] = "10.11.5." + strconv.Itoa(100+i) + ":2181"
+		if err := mpr.AddTarget(servers[i]); err != nil {
+			t.Errorf("Adding server Error: %s", err)
+			t.FailNow()
+		}
+	}
+	if mpr.nodeRing.Len() != len(servers) {
+		t.Errorf("The length '%d' of node ring should be %d.", mpr.nodeRing.Len(), len(servers))
+		t.FailNow()
+	}
+	// begin - test about balance
+	keyNumber := 50000
+	before := make(map[string]string, keyNumber)
+	counts := make(map[string]int)
+	for i := 0; i < keyNumber; i++ {
+		key := "key-" + strconv.Itoa(i)
+		target, err := mpr.GetTarget(key)
+		if err != nil || len(target) == 0 {
+			t.Errorf("Getting target of key '%s' is FAILING. (err=%v)", key, err)
+			t.FailNow()
+		}
+		before[key] = target
+		counts[target]++
+	}
+	maxCount := 0
+	for _, count := range counts {
+		if count > maxCount {
+			maxCount = count
+		}
+	}
+	peakToMean := float64(maxCount) / (float64(keyNumber) / float64(len(servers)))
+	t.Logf("The key counts: %v (peak-to-mean=%f)", counts, peakToMean)
+	if peakToMean > 1.3 {
+		t.Errorf("The peak-to-mean ratio '%f' of key counts is too large.", peakToMean)
+		t.FailNow()
+	}
+	// end - test about balance
+	// begin - test about check
+	invalidServer := servers[4]
+	mpr.Check(func(target string) bool { return target != invalidServer })
+	for key, target := range before {
+		current, _ := mpr.GetTarget(key)
+		if target != invalidServer && current != target {
+			t.Errorf("The key '%s' should not move from '%s' to '%s'.", key, target, current)
+			t.FailNow()
+		}
+		if current == invalidServer {
+			t.Errorf("The key '%s' should not be located at invalid target '%s'.", key, current)
+			t.FailNow()
+		}
+	}
+	mpr.Check(func(target string) bool { return true })
+	// end - test about check
+	firstTarget, _ := mpr.GetTarget("chash_test")
+	targets, _ := mpr.GetTargets("chash_test", 3)
+	if len(targets) != 3 || targets[0] != firstTarget {
+		t.Errorf("The targets %v of key '%s' are unexpected.", targets, "chash_test")
+		t.FailNow()
+	}
+	if err := mpr.RemoveTarget(targets[0]); err != nil {
+		t.Errorf("Removing target '%s' Error: %s", targets[0], err)
+		t.FailNow()
+	}
+	target, _ := mpr.GetTarget("chash_test")
+	if target != targets[1] {
+		t.Errorf("The target '%s' of key '%s' should be '%s'.", target, "chash_test", targets[1])
+		t.FailNow()
+	}
+	if err := mpr.Destroy(); err != nil || mpr.Status() != DESTROYED {
+		t.Errorf("Destroy hash ring is FAILING. (err=%v)", err)
+		t.FailNow()
+	}
+}
@@ -99,33 +99,40 @@ func (self *NodeRing) Next(nodeKey uint64) *Node {
 	return &Node{matchedKey, self.nodeMap[matchedKey]}
 }
 
+// Add the nodes into the ring. The keys of added nodes are returned in the
+// order of parameters. The new keys are merged into the sorted keys of ring.
 func (self *NodeRing) Add(nodes ...Node) ([]uint64, bool) {
 	paramLength := len(nodes)
 	if paramLength == 0 {
 		return nil, false
 	}
-	newNodeKeys := make([]uint64, paramLength)
-	newIndex := 0
-	for i := 0; i < paramLength; i++ {
-		node := nodes[i]
-		nodeKey := node.Key
-		_, exists := self.nodeMap[nodeKey]
-		if !exists {
-			self.nodeMap[nodeKey] = node.Target
-			lengthOfNew := len(newNodeKeys)
-			indexOfNew := sort.Search(lengthOfNew, func(i int) bool { return newNodeKeys[i] >= nodeKey })
-			if indexOfNew >= lengthOfNew || newNodeKeys[indexOfNew] != nodeKey {
-				newNodeKeys[newIndex] = nodeKey
-				newIndex++
-			}
+	newNodeKeys := make([]uint64, 0, paramLength)
+	for _, node := range nodes {
+		if _, exists := self.nodeMap[node.Key]; !exists {
+			self.nodeMap[node.Key] = node.Target
+			newNodeKeys = append(newNodeKeys, node.Key)
 		}
 	}
-	if newIndex == 0 {
+	if len(newNodeKeys) == 0 {
 		return nil, false
 	}
-	newNodeKeys = newNodeKeys[:newIndex]
-	self.nodeKeys = append(self.nodeKeys, newNodeKeys...)
-	sort.Sort(self)
+	sortedNodeKeys := make([]uint64, len(newNodeKeys))
+	copy(sortedNodeKeys, newNodeKeys)
+	sort.Slice(sortedNodeKeys, func(i, j int) bool { return sortedNodeKeys[i] < sortedNodeKeys[j] })
+	mergedNodeKeys := make([]uint64, 0, len(self.nodeKeys)+len(sortedNodeKeys))
+	i, j := 0, 0
+	for i < len(self.nodeKeys) && j < len(sortedNodeKeys) {
+		if self.nodeKeys[i] < sortedNodeKeys[j] {
+			mergedNodeKeys = append(mergedNodeKeys, self.nodeKeys[i])
+			i++
+		} else {
+			mergedNodeKeys = append(mergedNodeKeys, sortedNodeKeys[j])
+			j++
+		}
+	}
+	mergedNodeKeys = append(mergedNodeKeys, self.nodeKeys[i:]...)
+	mergedNodeKeys = append(mergedNodeKeys, sortedNodeKeys[j:]...)
+	self.nodeKeys = mergedNodeKeys
 	kLen := len(self.nodeKeys)
 	mLen := len(self.nodeMap)
 	if kLen != mLen {
@@ -153,6 +160,17 @@ func (self *NodeRing) Remove(nodeKey uint64) bool {
 	return false
 }
 
+// Get a copy of the ring which can be changed without affecting the ring.
+func (self *NodeRing) Clone() *NodeRing {
+	nodeKeys := make([]uint64, len(self.nodeKeys))
+	copy(nodeKeys, self.nodeKeys)
+	nodeMap := make(map[uint64]string, len(self.nodeMap))
+	for nodeKey, target := range self.nodeMap {
+		nodeMap[nodeKey] = target
+	}
+	return &NodeRing{nodeKeys: nodeKeys, nodeMap: nodeMap}
+}
+
 func NewNodeRing() *NodeRing {
 	return &NodeRing{nodeKeys: make([]uint64, 0), nodeMap: make(map[uint64]string)}
 }
@@ -131,3 +131,20 @@ func TestNodeRing(t *testing.T) {
 		t.Logf("The node (key=%v) is removed. (Remove)", node.Key)
 	}
 }
+
+func TestNodeRingClone(t *testing.T) {
+	nr := NewNodeRing()
+	nr.Add(Node{1, "A"}, Node{3, "C"})
+	clone := nr.Clone()
+	clone.Add(Node{2, "B"})
+	clone.Remove(1)
+	if nr.Len() != 2 || nr.Get(1) == nil || nr.Get(2) != nil {
+		t.Errorf("The original ring %v should not be changed by its clone.", nr.GetAllNodeKey())
+		t.FailNow()
+	}
+	nodeKeys := clone.GetAllNodeKey()
+	if len(nodeKeys) != 2 || nodeKeys[0] != 2 || nodeKeys[1] != 3 {
+		t.Errorf("The node keys %v of clone are unexpected.", nodeKeys)
+		t.FailNow()
+	}
+}
@@ -0,0 +1,232 @@
+package chash4go
+
+import (
+	"time"
+)
+
+type Option func(ring *SimpleHashRing) error
+
+// Set the shadow number of each target with the default weight.
+func WithShadowNumber(shadowNumber uint16) Option {
+	return func(ring *SimpleHashRing) error {
+		if shadowNumber == 0 {
+			return &ArgumentError{"shadowNumber", shadowNumber, "It should be greater than 0."}
+		}
+		ring.shadowNumber = shadowNumber
+		return nil
+	}
+}
+
+// Set the hasher for both the placement of nodes & the lookup of keys.
+func WithHasher(hasher Hasher) Option {
+	return func(ring *SimpleHashRing) error {
+		if err := validateHasher(hasher); err != nil {
+			return err
+		}
+		ring.NodeHasher = hasher
+		ring.KeyHasher = hasher
+		return nil
+	}
+}
+
+func WithNodeHasher(hasher Hasher) Option {
+	return func(ring *SimpleHashRing) error {
+		if err := validateHasher(hasher); err != nil {
+			return err
+		}
+		ring.NodeHasher = hasher
+		return nil
+	}
+}
+
+func WithKeyHasher(hasher Hasher) Option {
+	return func(ring *SimpleHashRing) error {
+		if err := validateHasher(hasher); err != nil {
+			return err
+		}
+		ring.KeyHasher = hasher
+		return nil
+	}
+}
+
+func WithKeyHashVersion(version KeyHashVersion) Option {
+	return func(ring *SimpleHashRing) error {
+		if version != KEY_HASH_V1 && version != KEY_HASH_V2 {
+			return &ArgumentError{"version", version, "It is unsupported."}
+		}
+		ring.KeyHashVersion = version
+		return nil
+	}
+}
+
+func WithCompatibility(profile CompatibilityProfile) Option {
+	return func(ring *SimpleHashRing) error {
+		if profile != LIBKETAMA {
+			return &ArgumentError{"profile", profile, "It is unsupported."}
+		}
+		ring.Compatibility = profile
+		return nil
+	}
+}
+
+// Set the checker which is started by 'StartCheck' instead of the cycle checker.
+func WithChecker(checker Checker) Option {
+	return func(ring *SimpleHashRing) error {
+		if checker == nil {
+			return &ArgumentError{"checker", checker, "It is nil."}
+		}
+		ring.customChecker = checker
+		return nil
+	}
+}
+
+// Set the interval & jitter of the cycle checker. The interval overrides the
+// seconds of 'StartCheck'.
+func WithCheckInterval(interval time.Duration, jitter time.Duration) Option {
+	return func(ring *SimpleHashRing) error {
+		if interval <= 0 {
+			return &ArgumentError{"interval", interval, "It should be greater than 0."}
+		}
+		if jitter < 0 {
+			return &ArgumentError{"jitter", jitter, "It should not be less than 0."}
+		}
+		ring.checkInterval = interval
+		ring.checkJitter = jitter
+		return nil
+	}
+}
+
+func WithLogger(logger Logger) Option {
+	return func(ring *SimpleHashRing) error {
+		if logger == nil {
+			return &ArgumentError{"logger", logger, "It is nil."}
+		}
+		ring.SetLogger(logger)
+		return nil
+	}
+}
+
+// Set the timeout of each health check. It also applies to the node checks
+// of 'Check' & 'StartCheck', which have no timeout by default.
+func WithCheckTimeout(timeout time.Duration) Option {
+	return func(ring *SimpleHashRing) error {
+		if timeout <= 0 {
+			return &ArgumentError{"timeout", timeout, "It should be greater than 0."}
+		}
+		ring.checkTimeout = timeout
+		return nil
+	}
+}
+
+// Set the number of health checks which run at the same time.
+func WithCheckConcurrency(concurrency int) Option {
+	return func(ring *SimpleHashRing) error {
+		if concurrency <= 0 {
+			return &ArgumentError{"concurrency", concurrency, "It should be greater than 0."}
+		}
+		ring.checkConcurrency = concurrency
+		return nil
+	}
+}
+
+// Set the number of consecutive successes to re-admit a target ('rise'),
+// and the number of consecutive failures to eject a target ('fall').
+func WithCheckThresholds(rise int, fall int) Option {
+	return func(ring *SimpleHashRing) error {
+		if rise <= 0 {
+			return &ArgumentError{"rise", rise, "It should be greater than 0."}
+		}
+		if fall <= 0 {
+			return &ArgumentError{"fall", fall, "It should be greater than 0."}
+		}
+		ring.riseThreshold = rise
+		ring.fallThreshold = fall
+		return nil
+	}
+}
+
+// Enable the flap damping of targets. The zero fields of damping are set to the defaults.
+func WithFlapDamping(damping FlapDamping) Option {
+	return func(ring *SimpleHashRing) error {
+		if err := damping.normalize(); err != nil {
+			return err
+		}
+		ring.flapDamping = &damping
+		return nil
+	}
+}
+
+// Enable the outlier detection by the results which are reported by 'ReportResult'.
+// The zero fields of detection are set to the defaults.
+func WithOutlierDetection(detection OutlierDetection) Option {
+	return func(ring *SimpleHashRing) error {
+		if err := detection.normalize(); err != nil {
+			return err
+		}
+		ring.outlierDetection = &detection
+		return nil
+	}
+}
+
+// Enable the per-target circuit breakers which are fed by 'ReportResult'.
+// The zero fields of breaker are set to the defaults.
+func WithCircuitBreaker(breaker CircuitBreaker) Option {
+	return func(ring *SimpleHashRing) error {
+		if err := breaker.normalize(); err != nil {
+			return err
+		}
+		ring.circuitBreaker = &breaker
+		return nil
+	}
+}
+
+// Enable the ramping of the weights of the added, re-admitted & draining targets.
+// The zero fields of ramp are set to the defaults. It is ignored by the compatibility profile.
+func WithWeightRamp(ramp WeightRamp) Option {
+	return func(ring *SimpleHashRing) error {
+		if err := ramp.normalize(); err != nil {
+			return err
+		}
+		ring.weightRamp = &ramp
+		return nil
+	}
+}
+
+// Set the minimum percentage of healthy targets. The ring enters panic mode if
+// the percentage falls below it. The target is healthy by its state after the
+// check thresholds, the flap damping & the outlier ejection are applied. It is
+// disabled by default.
+func WithPanicThreshold(percent float64) Option {
+	return func(ring *SimpleHashRing) error {
+		if percent < 0 || percent > 100 {
+			return &ArgumentError{"percent", percent, "It should be in [0, 100]."}
+		}
+		ring.panicThreshold = percent
+		return nil
+	}
+}
+
+// Set the listener of ring events. See 'RingEventFunc' for the restrictions of it.
+func WithEventListener(listener RingEventFunc) Option {
+	return func(ring *SimpleHashRing) error {
+		if listener == nil {
+			return &ArgumentError{"listener", listener, "It is nil."}
+		}
+		ring.eventListener = listener
+		return nil
+	}
+}
+
+// Create a builded simple hash ring with options.
+func NewSimpleHashRing(opts ...Option) (*SimpleHashRing, error) {
+	ring := &SimpleHashRing{}
+	for _, opt := range opts {
+		if err := opt(ring); err != nil {
+			return nil, err
+		}
+	}
+	if err := ring.Build(ring.shadowNumber); err != nil {
+		return nil, err
+	}
+	return ring, nil
+}
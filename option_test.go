@@ -0,0 +1,130 @@
+package chash4go
+
+import (
+	"testing"
+)
+
+type countingChecker struct {
+	started bool
+	count   int
+}
+
+func (self *countingChecker) Start(checkFunc CheckFunc) bool {
+	self.started = true
+	checkFunc()
+	self.count++
+	return true
+}
+
+func (self *countingChecker) Stop() bool {
+	self.started = false
+	return true
+}
+
+func (self *countingChecker) InChecking() bool {
+	return self.started
+}
+
+func TestNewSimpleHashRing(t *testing.T) {
+	if _, err := NewSimpleHashRing(WithShadowNumber(0)); err == nil {
+		t.Errorf("Creating hash ring with zero shadow number should be FAILING.")
+		t.FailNow()
+	}
+	if _, err := NewSimpleHashRing(WithHasher(nil)); err == nil {
+		t.Errorf("Creating hash ring with nil hasher should be FAILING.")
+		t.FailNow()
+	}
+	checker := &countingChecker{}
+	shr, err := NewSimpleHashRing(
+		WithShadowNumber(200),
+		WithNodeHasher(MD5Hasher{}),
+		WithKeyHasher(Murmur3Hasher{}),
+		WithChecker(checker),
+		WithLogger(logger))
+	if err != nil {
+		t.Errorf("Creating hash ring Error: %s", err)
+		t.FailNow()
+	}
+	if shr.Status() != BUILDED {
+		t.Errorf("The status '%v' should '%v'. ", shr.Status(), BUILDED)
+		t.FailNow()
+	}
+	if shr.shadowNumber != 200 {
+		t.Errorf("The shadow number '%d' should be %d.", shr.shadowNumber, 200)
+		t.FailNow()
+	}
+	if _, ok := shr.NodeHasher.(MD5Hasher); !ok {
+		t.Errorf("The node hasher %T should be MD5Hasher.", shr.NodeHasher)
+		t.FailNow()
+	}
+	if _, ok := shr.KeyHasher.(Murmur3Hasher); !ok {
+		t.Errorf("The key hasher %T should be Murmur3Hasher.", shr.KeyHasher)
+		t.FailNow()
+	}
+	shr.AddTarget("10.11.5.145:2181")
+	done, err := shr.StartCheck(func(target string) bool { return true }, 1)
+	if err != nil || !done {
+		t.Errorf("Starting check is FAILING. (err=%v)", err)
+		t.FailNow()
+	}
+	if checker.count != 1 || !shr.InChecking() {
+		t.Errorf("The custom checker should be started.")
+		t.FailNow()
+	}
+	shr.StopCheck()
+	if shr.InChecking() {
+		t.Errorf("The custom checker should be stopped.")
+		t.FailNow()
+	}
+}
+
+func TestHashRingImplementations(t *testing.T) {
+	servers := [...]string{"10.11.156.71:2181", "10.11.5.145:2181", "10.11.5.164:2181"}
+	simpleHashRing, _ := NewSimpleHashRing()
+	hashRings := []HashRing{simpleHashRing, &JumpHashRing{}, &RendezvousRing{}, &MaglevRing{}, &MultiProbeRing{}}
+	for _, hashRing := range hashRings {
+		if hashRing.Status() != BUILDED {
+			hashRing.Build(0)
+		}
+		for _, s := range servers {
+			if err := hashRing.AddTarget(s); err != nil {
+				t.Errorf("Adding server to %T Error: %s", hashRing, err)
+				t.FailNow()
+			}
+		}
+		if err := hashRing.AddTarget(servers[0]); err == nil {
+			t.Errorf("Adding server '%s' to %T again should be FAILING.", servers[0], hashRing)
+			t.FailNow()
+		}
+		invalidServer := servers[1]
+		hashRing.Check(func(target string) bool { return target != invalidServer })
+		if !hashRing.ContainsTarget(invalidServer) {
+			t.Errorf("The %T should contain the invalid target '%s'.", hashRing, invalidServer)
+			t.FailNow()
+		}
+		activeTargets := hashRing.GetActiveTargets()
+		pendingTargets := hashRing.GetPendingTargets()
+		if len(activeTargets) != len(servers)-1 || len(pendingTargets) != 1 || pendingTargets[0] != invalidServer {
+			t.Errorf("The active targets %v & pending targets %v of %T are unexpected.", activeTargets, pendingTargets, hashRing)
+			t.FailNow()
+		}
+		targets, err := hashRing.GetTargets("chash_test", len(servers))
+		if err != nil || len(targets) != len(servers)-1 {
+			t.Errorf("The targets %v of %T are unexpected. (err=%v)", targets, hashRing, err)
+			t.FailNow()
+		}
+		if err := hashRing.RemoveTarget(invalidServer); err != nil {
+			t.Errorf("Removing target '%s' from %T Error: %s", invalidServer, hashRing, err)
+			t.FailNow()
+		}
+		if hashRing.ContainsTarget(invalidServer) {
+			t.Errorf("The %T should not contain the removed target '%s'.", hashRing, invalidServer)
+			t.FailNow()
+		}
+		if err := hashRing.RemoveTarget(invalidServer); err == nil {
+			t.Errorf("Removing target '%s' from %T again should be FAILING.", invalidServer, hashRing)
+			t.FailNow()
+		}
+		hashRing.Destroy()
+	}
+}
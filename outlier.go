@@ -0,0 +1,375 @@
+package chash4go
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// The defaults of outlier detection which are the same as the ones of Envoy.
+const (
+	DEFAULT_CONSECUTIVE_ERRORS   int           = 5
+	DEFAULT_OUTLIER_INTERVAL     time.Duration = 10 * time.Second
+	DEFAULT_BASE_EJECTION_TIME   time.Duration = 30 * time.Second
+	DEFAULT_MAX_EJECTION_TIME    time.Duration = 300 * time.Second
+	DEFAULT_MAX_EJECTION_PERCENT float64       = 10
+	DEFAULT_OUTLIER_MIN_HOSTS    int           = 5
+	DEFAULT_REQUEST_VOLUME       int           = 100
+	DEFAULT_STDEV_FACTOR         float64       = 1.9
+)
+
+/*
+ * The outlier detection by the results of requests which are reported by
+ * 'ReportResult' (like the outlier detection of Envoy). A target is ejected if:
+ *   - it has 'ConsecutiveErrors' consecutive errors;
+ *   - its success rate in an interval is below 'mean - StdevFactor * stdev' of
+ *     the success rates of the targets;
+ *   - its mean latency in an interval is above 'LatencyFactor' times the median
+ *     of the mean latencies of the targets. It is disabled if the factor is zero.
+ * The rates & latencies are compared only if there are at least 'MinHosts' targets
+ * which have 'RequestVolume' requests in the interval. The ejected target is
+ * re-admitted after 'BaseEjectionTime' multiplied by the number of its recent
+ * ejections (at most 'MaxEjectionTime'). At most 'MaxEjectionPercent' of targets
+ * (but at least one) are ejected at the same time.
+ */
+type OutlierDetection struct {
+	ConsecutiveErrors  int
+	Interval           time.Duration
+	BaseEjectionTime   time.Duration
+	MaxEjectionTime    time.Duration
+	MaxEjectionPercent float64
+	MinHosts           int
+	RequestVolume      int
+	StdevFactor        float64
+	LatencyFactor      float64
+}
+
+type OutlierDetector string
+
+// Outlier detector
+const (
+	CONSECUTIVE_ERRORS OutlierDetector = "CONSECUTIVE_ERRORS"
+	SUCCESS_RATE       OutlierDetector = "SUCCESS_RATE"
+	LATENCY            OutlierDetector = "LATENCY"
+)
+
+// The ejection reason of outlier. It wraps 'ErrOutlier' and the last error of requests.
+type OutlierError struct {
+	Detector OutlierDetector
+	Err      error
+}
+
+func (self *OutlierError) Error() string {
+	if self.Err == nil {
+		return fmt.Sprintf("The target is an outlier. (detector=%s)", self.Detector)
+	}
+	return fmt.Sprintf("The target is an outlier: %s (detector=%s)", self.Err, self.Detector)
+}
+
+func (self *OutlierError) Unwrap() []error {
+	if self.Err == nil {
+		return []error{ErrOutlier}
+	}
+	return []error{ErrOutlier, self.Err}
+}
+
+// The results of requests to a target in the current interval.
+type outlierStat struct {
+	successes         int
+	failures          int
+	latency           time.Duration
+	consecutiveErrors int
+	lastError         error
+}
+
+// Fill the zero fields with the defaults, and validate the detection.
+func (self *OutlierDetection) normalize() error {
+	if self.ConsecutiveErrors == 0 {
+		self.ConsecutiveErrors = DEFAULT_CONSECUTIVE_ERRORS
+	}
+	if self.Interval == 0 {
+		self.Interval = DEFAULT_OUTLIER_INTERVAL
+	}
+	if self.BaseEjectionTime == 0 {
+		self.BaseEjectionTime = DEFAULT_BASE_EJECTION_TIME
+	}
+	if self.MaxEjectionTime == 0 {
+		self.MaxEjectionTime = DEFAULT_MAX_EJECTION_TIME
+	}
+	if self.MaxEjectionPercent == 0 {
+		self.MaxEjectionPercent = DEFAULT_MAX_EJECTION_PERCENT
+	}
+	if self.MinHosts == 0 {
+		self.MinHosts = DEFAULT_OUTLIER_MIN_HOSTS
+	}
+	if self.RequestVolume == 0 {
+		self.RequestVolume = DEFAULT_REQUEST_VOLUME
+	}
+	if self.StdevFactor == 0 {
+		self.StdevFactor = DEFAULT_STDEV_FACTOR
+	}
+	switch {
+	case self.ConsecutiveErrors < 0:
+		return &ArgumentError{"ConsecutiveErrors", self.ConsecutiveErrors, "It should be greater than 0."}
+	case self.Interval < 0:
+		return &ArgumentError{"Interval", self.Interval, "It should be greater than 0."}
+	case self.BaseEjectionTime < 0:
+		return &ArgumentError{"BaseEjectionTime", self.BaseEjectionTime, "It should be greater than 0."}
+	case self.MaxEjectionTime < self.BaseEjectionTime:
+		return &ArgumentError{"MaxEjectionTime", self.MaxEjectionTime, "It should not be less than the base ejection time."}
+	case self.MaxEjectionPercent < 0 || self.MaxEjectionPercent > 100:
+		return &ArgumentError{"MaxEjectionPercent", self.MaxEjectionPercent, "It should be in [0, 100]."}
+	case self.MinHosts < 0:
+		return &ArgumentError{"MinHosts", self.MinHosts, "It should be greater than 0."}
+	case self.RequestVolume < 0:
+		return &ArgumentError{"RequestVolume", self.RequestVolume, "It should be greater than 0."}
+	case self.StdevFactor < 0:
+		return &ArgumentError{"StdevFactor", self.StdevFactor, "It should be greater than 0."}
+	case self.LatencyFactor != 0 && self.LatencyFactor <= 1:
+		return &ArgumentError{"LatencyFactor", self.LatencyFactor, "It should be greater than 1."}
+	}
+	return nil
+}
+
+// Find the outliers by the success rates & mean latencies of targets in an interval.
+func (self *OutlierDetection) detect(statMap map[string]outlierStat) map[string]error {
+	outliers := make(map[string]error)
+	targets := make([]string, 0, len(statMap))
+	for target, stat := range statMap {
+		if stat.successes+stat.failures >= self.RequestVolume {
+			targets = append(targets, target)
+		}
+	}
+	if len(targets) < self.MinHosts || len(targets) == 0 {
+		return outliers
+	}
+	sort.Strings(targets)
+	rates := make([]float64, len(targets))
+	latencies := make([]float64, len(targets))
+	var rateSum float64
+	for i, target := range targets {
+		stat := statMap[target]
+		total := stat.successes + stat.failures
+		rates[i] = float64(stat.successes) / float64(total)
+		latencies[i] = float64(stat.latency) / float64(total)
+		rateSum += rates[i]
+	}
+	mean := rateSum / float64(len(rates))
+	var variance float64
+	for _, rate := range rates {
+		variance += (rate - mean) * (rate - mean)
+	}
+	stdev := math.Sqrt(variance / float64(len(rates)))
+	for i, target := range targets {
+		if rates[i] < mean-self.StdevFactor*stdev {
+			outliers[target] = &OutlierError{SUCCESS_RATE, statMap[target].lastError}
+		}
+	}
+	if self.LatencyFactor > 0 {
+		sortedLatencies := append([]float64(nil), latencies...)
+		sort.Float64s(sortedLatencies)
+		median := sortedLatencies[len(sortedLatencies)/2]
+		if len(sortedLatencies)%2 == 0 {
+			median = (median + sortedLatencies[len(sortedLatencies)/2-1]) / 2
+		}
+		for i, target := range targets {
+			if _, exists := outliers[target]; !exists && latencies[i] > self.LatencyFactor*median {
+				outliers[target] = &OutlierError{LATENCY, nil}
+			}
+		}
+	}
+	return outliers
+}
+
+// Report the result of a request to the target for the circuit breakers and
+// the outlier detection. It is ignored if neither of them is enabled.
+func (self *SimpleHashRing) ReportResult(target string, err error, latency time.Duration) {
+	now := time.Now()
+	if self.circuitBreaker != nil {
+		self.getBreaker(target, now).record(self.circuitBreaker, err, now)
+	}
+	self.reportResult(target, err, latency, now)
+}
+
+func (self *SimpleHashRing) reportResult(target string, err error, latency time.Duration, now time.Time) {
+	detection := self.outlierDetection
+	if detection == nil {
+		return
+	}
+	var outliers map[string]error
+	var statMap map[string]outlierStat
+	self.outlierLock.Lock()
+	if self.outlierStatMap == nil {
+		self.outlierStatMap = make(map[string]*outlierStat)
+		self.outlierTime = now
+	}
+	stat, exists := self.outlierStatMap[target]
+	if !exists {
+		stat = &outlierStat{}
+		self.outlierStatMap[target] = stat
+	}
+	stat.latency += latency
+	if err != nil {
+		stat.failures++
+		stat.consecutiveErrors++
+		stat.lastError = err
+		if stat.consecutiveErrors >= detection.ConsecutiveErrors {
+			stat.consecutiveErrors = 0
+			outliers = map[string]error{target: &OutlierError{CONSECUTIVE_ERRORS, err}}
+		}
+	} else {
+		stat.successes++
+		stat.consecutiveErrors = 0
+	}
+	if now.Sub(self.outlierTime) >= detection.Interval {
+		statMap = make(map[string]outlierStat, len(self.outlierStatMap))
+		for target, stat := range self.outlierStatMap {
+			statMap[target] = *stat
+			*stat = outlierStat{consecutiveErrors: stat.consecutiveErrors}
+		}
+		self.outlierTime = now
+	}
+	self.outlierLock.Unlock()
+	if statMap != nil {
+		if outliers == nil {
+			outliers = make(map[string]error)
+		}
+		for target, reason := range detection.detect(statMap) {
+			if _, exists := outliers[target]; !exists {
+				outliers[target] = reason
+			}
+		}
+	}
+	if outliers != nil || statMap != nil {
+		self.applyOutliers(outliers, now, statMap != nil)
+	}
+}
+
+// Schedule the re-admission of the ejected targets at the earliest end of their
+// ejection times, so that they come back without any reported result.
+// It should be called with the lock held.
+func (self *SimpleHashRing) scheduleReadmission(now time.Time) {
+	if self.outlierTimer != nil {
+		self.outlierTimer.Stop()
+		self.outlierTimer = nil
+	}
+	var readmissionTime time.Time
+	for target := range self.pendingTargetMap {
+		health, exists := self.healthMap[target]
+		if !exists || health.EjectedUntil.IsZero() {
+			continue
+		}
+		if readmissionTime.IsZero() || health.EjectedUntil.Before(readmissionTime) {
+			readmissionTime = health.EjectedUntil
+		}
+	}
+	if readmissionTime.IsZero() {
+		return
+	}
+	self.outlierTimer = time.AfterFunc(readmissionTime.Sub(now), func() {
+		self.applyOutliers(nil, time.Now(), false)
+	})
+}
+
+// Eject the outliers, and re-admit the ejected targets whose ejection time is
+// over. The ejection counts of the targets which stay active are decreased
+// at the end of each interval.
+func (self *SimpleHashRing) applyOutliers(outliers map[string]error, now time.Time, intervalEnded bool) {
+	self.changeLock.Lock()
+	defer self.changeLock.Unlock()
+	if self.status != BUILDED {
+		return
+	}
+	detection := self.outlierDetection
+	changed := false
+	changeNodeRing := func() *NodeRing {
+		if !changed {
+			self.nodeRing = self.nodeRing.Clone()
+			changed = true
+		}
+		return self.nodeRing
+	}
+	ejectedNumber := 0
+	readmitted := make(map[string]bool)
+	for _, target := range self.getPendingTargets() {
+		health, exists := self.healthMap[target]
+		if !exists || health.EjectedUntil.IsZero() {
+			continue
+		}
+		if now.Before(health.EjectedUntil) {
+			ejectedNumber++
+			continue
+		}
+		health.EjectedUntil = time.Time{}
+		if health.ConsecutiveFailures > 0 || self.isManualState(target) {
+			// It is left to the health checks or the operator.
+			continue
+		}
+		self.getLogger().Infof("Re-admitting outlier target '%s'...", target)
+		changeNodeRing()
+		if self.readmitTarget(target, now) {
+			health.Reason = nil
+			readmitted[target] = true
+			self.setTargetState(target, TARGET_ACTIVE, "", false, now)
+		}
+	}
+	targets := make([]string, 0, len(outliers))
+	for target := range outliers {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+	for _, target := range targets {
+		nodeKeys, active := self.targetMap[target]
+		if !active || self.inPanic {
+			continue
+		}
+		if len(self.targetMap) <= 1 {
+			self.getLogger().Warnf("Skip ejecting outlier target '%s' since it is the last active target.", target)
+			continue
+		}
+		if ejectedNumber > 0 && float64(ejectedNumber+1)*100 > detection.MaxEjectionPercent*float64(len(self.weightMap)) {
+			self.getLogger().Warnf("Skip ejecting outlier target '%s' due to the max ejection percent. (ejected=%d)", target, ejectedNumber)
+			continue
+		}
+		health, exists := self.healthMap[target]
+		if !exists {
+			health = &TargetHealth{}
+			self.healthMap[target] = health
+		}
+		health.ejectionCount++
+		ejectionTime := detection.BaseEjectionTime * time.Duration(health.ejectionCount)
+		if ejectionTime > detection.MaxEjectionTime {
+			ejectionTime = detection.MaxEjectionTime
+		}
+		health.EjectedUntil = now.Add(ejectionTime)
+		health.Reason = outliers[target]
+		self.getLogger().Infof("Ejecting outlier target '%s' for %v... (reason=%s)", target, ejectionTime, health.Reason)
+		if self.removeNodeByKeys(changeNodeRing(), nodeKeys) {
+			self.pendingTargetMap[target] = nodeKeys
+			delete(self.targetMap, target)
+			if !self.isManualState(target) {
+				self.setTargetState(target, TARGET_DOWN, health.Reason.Error(), false, now)
+			}
+			ejectedNumber++
+		}
+	}
+	if intervalEnded {
+		for target := range self.targetMap {
+			if health, exists := self.healthMap[target]; exists && health.ejectionCount > 0 && !readmitted[target] {
+				health.ejectionCount--
+			}
+		}
+		self.outlierLock.Lock()
+		for target := range self.outlierStatMap {
+			if _, exists := self.weightMap[target]; !exists {
+				delete(self.outlierStatMap, target)
+			}
+		}
+		self.outlierLock.Unlock()
+	}
+	if changed {
+		self.publish()
+	}
+	self.scheduleReadmission(now)
+}
@@ -0,0 +1,183 @@
+package chash4go
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func newOutlierTestRing(t *testing.T, targetNumber int, detection OutlierDetection) *SimpleHashRing {
+	shr, err := NewSimpleHashRing(WithShadowNumber(10), WithOutlierDetection(detection))
+	if err != nil {
+		t.Errorf("Creating hash ring Error: %s", err)
+		t.FailNow()
+	}
+	for i := 0; i < targetNumber; i++ {
+		shr.AddTarget("10.11.5." + strconv.Itoa(i) + ":2181")
+	}
+	return shr
+}
+
+func TestSimpleHashRingWithConsecutiveErrors(t *testing.T) {
+	shr := newOutlierTestRing(t, 10, OutlierDetection{ConsecutiveErrors: 3, MaxEjectionPercent: 20})
+	target := "10.11.5.0:2181"
+	requestError := errors.New("i/o timeout")
+	now := time.Now()
+	for i := 0; i < 2; i++ {
+		shr.reportResult(target, requestError, time.Millisecond, now)
+	}
+	shr.reportResult(target, nil, time.Millisecond, now)
+	shr.reportResult(target, requestError, time.Millisecond, now)
+	if len(shr.GetPendingTargets()) != 0 {
+		t.Errorf("The target '%s' should not be ejected without consecutive errors.", target)
+		t.FailNow()
+	}
+	shr.reportResult(target, requestError, time.Millisecond, now)
+	shr.reportResult(target, requestError, time.Millisecond, now)
+	reason := shr.GetEjectionReason(target)
+	var outlierError *OutlierError
+	if !errors.As(reason, &outlierError) || outlierError.Detector != CONSECUTIVE_ERRORS ||
+		!errors.Is(reason, ErrOutlier) || !errors.Is(reason, requestError) {
+		t.Errorf("The target '%s' should be ejected by consecutive errors. (reason=%v)", target, reason)
+		t.FailNow()
+	}
+	health, _ := shr.GetTargetHealth(target)
+	if expected := now.Add(DEFAULT_BASE_EJECTION_TIME); !health.EjectedUntil.Equal(expected) {
+		t.Errorf("The target '%s' should be ejected until %v. (but %v)", target, expected, health.EjectedUntil)
+		t.FailNow()
+	}
+	// The ejected target is not re-admitted by the health checks before the ejection time is over.
+	shr.HealthCheck(context.Background(), func(ctx context.Context, target string) error { return nil })
+	if _, active := shr.GetWeight(target); active {
+		t.Errorf("The target '%s' should not be re-admitted by health check.", target)
+		t.FailNow()
+	}
+	// At most 2 targets (20%) are ejected.
+	for i := 1; i < 4; i++ {
+		for j := 0; j < 3; j++ {
+			shr.reportResult("10.11.5."+strconv.Itoa(i)+":2181", requestError, time.Millisecond, now)
+		}
+	}
+	if pendingTargets := shr.GetPendingTargets(); len(pendingTargets) != 2 {
+		t.Errorf("The ejected targets %v should be limited by the max ejection percent.", pendingTargets)
+		t.FailNow()
+	}
+	shr.reportResult("10.11.5.9:2181", nil, time.Millisecond, now.Add(DEFAULT_BASE_EJECTION_TIME))
+	if pendingTargets := shr.GetPendingTargets(); len(pendingTargets) != 0 {
+		t.Errorf("The ejected targets %v should be re-admitted.", pendingTargets)
+		t.FailNow()
+	}
+	if reason := shr.GetEjectionReason(target); reason != nil {
+		t.Errorf("The re-admitted target '%s' should not have ejection reason. (but %v)", target, reason)
+		t.FailNow()
+	}
+	// The ejection time is increased by the ejection count.
+	now = now.Add(DEFAULT_BASE_EJECTION_TIME + time.Second)
+	for i := 0; i < 3; i++ {
+		shr.reportResult(target, requestError, time.Millisecond, now)
+	}
+	health, _ = shr.GetTargetHealth(target)
+	if expected := now.Add(2 * DEFAULT_BASE_EJECTION_TIME); !health.EjectedUntil.Equal(expected) {
+		t.Errorf("The target '%s' should be ejected until %v. (but %v)", target, expected, health.EjectedUntil)
+		t.FailNow()
+	}
+}
+
+func TestSimpleHashRingWithOutlierRates(t *testing.T) {
+	detection := OutlierDetection{RequestVolume: 10, LatencyFactor: 3, MaxEjectionPercent: 50}
+	shr := newOutlierTestRing(t, 7, detection)
+	failingTarget := "10.11.5.0:2181"
+	slowTarget := "10.11.5.1:2181"
+	idleTarget := "10.11.5.6:2181"
+	requestError := errors.New("internal server error")
+	now := time.Now()
+	for i := 0; i < 20; i++ {
+		for j := 0; j < 6; j++ {
+			target := "10.11.5." + strconv.Itoa(j) + ":2181"
+			var err error
+			latency := 10 * time.Millisecond
+			switch {
+			case target == failingTarget && i%2 == 0:
+				err = requestError
+			case target == slowTarget:
+				latency = 100 * time.Millisecond
+			}
+			shr.reportResult(target, err, latency, now)
+		}
+	}
+	if len(shr.GetPendingTargets()) != 0 {
+		t.Errorf("The targets should not be ejected before the interval is over. (pending=%v)", shr.GetPendingTargets())
+		t.FailNow()
+	}
+	shr.reportResult(idleTarget, nil, 10*time.Millisecond, now.Add(DEFAULT_OUTLIER_INTERVAL))
+	cases := []struct {
+		target   string
+		detector OutlierDetector
+	}{
+		{failingTarget, SUCCESS_RATE},
+		{slowTarget, LATENCY},
+	}
+	for _, c := range cases {
+		var outlierError *OutlierError
+		if reason := shr.GetEjectionReason(c.target); !errors.As(reason, &outlierError) || outlierError.Detector != c.detector {
+			t.Errorf("The target '%s' should be ejected by '%s'. (reason=%v)", c.target, c.detector, reason)
+			t.FailNow()
+		}
+	}
+	if len(shr.GetActiveTargets()) != 5 {
+		t.Errorf("Only the outliers should be ejected. (pending=%v)", shr.GetPendingTargets())
+		t.FailNow()
+	}
+}
+
+func TestSimpleHashRingForOutlierReadmission(t *testing.T) {
+	detection := OutlierDetection{ConsecutiveErrors: 1, BaseEjectionTime: 50 * time.Millisecond, MaxEjectionPercent: 50}
+	requestError := errors.New("i/o timeout")
+	// The last active target is not ejected.
+	shr := newOutlierTestRing(t, 1, detection)
+	target := "10.11.5.0:2181"
+	shr.ReportResult(target, requestError, time.Millisecond)
+	if _, err := shr.GetTarget("key"); err != nil {
+		t.Errorf("The last target '%s' should not be ejected. (error=%s)", target, err)
+		t.FailNow()
+	}
+	// The ejected target is re-admitted without any reported result.
+	shr = newOutlierTestRing(t, 2, detection)
+	defer shr.Destroy()
+	shr.ReportResult(target, requestError, time.Millisecond)
+	if _, active := shr.GetWeight(target); active {
+		t.Errorf("The target '%s' should be ejected.", target)
+		t.FailNow()
+	}
+	time.Sleep(detection.BaseEjectionTime + 100*time.Millisecond)
+	if _, active := shr.GetWeight(target); !active {
+		t.Errorf("The target '%s' should be re-admitted after the ejection time.", target)
+		t.FailNow()
+	}
+}
+
+func TestOutlierDetectionForValidation(t *testing.T) {
+	invalidDetections := []OutlierDetection{
+		{ConsecutiveErrors: -1},
+		{MaxEjectionPercent: 120},
+		{BaseEjectionTime: time.Hour},
+		{LatencyFactor: 0.5},
+	}
+	for i, detection := range invalidDetections {
+		if _, err := NewSimpleHashRing(WithOutlierDetection(detection)); !errors.Is(err, ErrInvalidArgument) {
+			t.Errorf("Creating hash ring with invalid detection should be '%v'. (but %v, case=%d)", ErrInvalidArgument, err, i)
+			t.FailNow()
+		}
+	}
+	shr, _ := NewSimpleHashRing(WithShadowNumber(10))
+	shr.AddTarget("10.11.5.145:2181")
+	for i := 0; i < 2*DEFAULT_CONSECUTIVE_ERRORS; i++ {
+		shr.ReportResult("10.11.5.145:2181", ErrUnhealthy, time.Millisecond)
+	}
+	if len(shr.GetActiveTargets()) != 1 {
+		t.Errorf("The results should be ignored without outlier detection.")
+		t.FailNow()
+	}
+}
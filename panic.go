@@ -0,0 +1,68 @@
+package chash4go
+
+import (
+	"time"
+)
+
+type RingEventType string
+
+// Ring event type
+const (
+	// The healthy percentage of targets falls below the panic threshold.
+	PANIC_ENTERED RingEventType = "PANIC_ENTERED"
+	// The healthy percentage of targets recovers to the panic threshold.
+	PANIC_EXITED RingEventType = "PANIC_EXITED"
+)
+
+type RingEvent struct {
+	Type           RingEventType
+	HealthyTargets int
+	TotalTargets   int
+	Time           time.Time
+}
+
+// The listener of ring events. It is called synchronously in the goroutine of
+// checks after the ring is changed, so it should return quickly, and it must not
+// call 'StopCheck' or 'Destroy' which wait for the checks to finish.
+type RingEventFunc func(event RingEvent)
+
+// Update the panic mode by the number of healthy targets in a check. In panic
+// mode, the targets are not ejected and the ejected ones are re-admitted, so that
+// the keys are routed to all known targets. The event is returned if the mode is
+// changed. It should be called with the lock held.
+func (self *SimpleHashRing) updatePanicMode(healthyNumber int, totalNumber int, now time.Time) *RingEvent {
+	if self.panicThreshold <= 0 || totalNumber == 0 {
+		return nil
+	}
+	inPanic := float64(healthyNumber)*100 < self.panicThreshold*float64(totalNumber)
+	if inPanic == self.inPanic {
+		return nil
+	}
+	self.inPanic = inPanic
+	event := &RingEvent{HealthyTargets: healthyNumber, TotalTargets: totalNumber, Time: now}
+	if inPanic {
+		event.Type = PANIC_ENTERED
+		self.getLogger().Warnf("Enter panic mode. (healthy=%d, total=%d)", healthyNumber, totalNumber)
+	} else {
+		event.Type = PANIC_EXITED
+		self.getLogger().Infof("Exit panic mode. (healthy=%d, total=%d)", healthyNumber, totalNumber)
+	}
+	return event
+}
+
+// Whether the ring is in panic mode.
+func (self *SimpleHashRing) InPanic() bool {
+	self.changeLock.RLock()
+	defer self.changeLock.RUnlock()
+	return self.inPanic
+}
+
+// Call the listener with the events in order. It should be called without the lock held.
+func (self *SimpleHashRing) fireEvents(events []RingEvent) {
+	if self.eventListener == nil {
+		return
+	}
+	for _, event := range events {
+		self.eventListener(event)
+	}
+}
@@ -0,0 +1,96 @@
+package chash4go
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+)
+
+func TestSimpleHashRingWithPanicThreshold(t *testing.T) {
+	events := make([]RingEvent, 0)
+	shr, err := NewSimpleHashRing(WithShadowNumber(10), WithPanicThreshold(50), WithEventListener(func(event RingEvent) {
+		events = append(events, event)
+	}))
+	if err != nil {
+		t.Errorf("Creating hash ring Error: %s", err)
+		t.FailNow()
+	}
+	targetNumber := 4
+	for i := 0; i < targetNumber; i++ {
+		shr.AddTarget("10.11.5." + strconv.Itoa(i) + ":2181")
+	}
+	healthyNumber := targetNumber
+	nodeCheckFunc := func(target string) bool {
+		for i := 0; i < healthyNumber; i++ {
+			if target == "10.11.5."+strconv.Itoa(i)+":2181" {
+				return true
+			}
+		}
+		return false
+	}
+	cases := []struct {
+		healthyNumber        int
+		expectedActiveNumber int
+		expectedInPanic      bool
+		expectedEvents       []RingEventType
+	}{
+		{2, 2, false, nil},
+		{1, 4, true, []RingEventType{PANIC_ENTERED}},
+		{0, 4, true, nil},
+		{3, 3, false, []RingEventType{PANIC_EXITED}},
+		{4, 4, false, nil},
+	}
+	for i, c := range cases {
+		healthyNumber = c.healthyNumber
+		events = events[:0]
+		shr.Check(nodeCheckFunc)
+		if len(shr.GetActiveTargets()) != c.expectedActiveNumber || shr.InPanic() != c.expectedInPanic {
+			t.Errorf("The state (active=%v, panic=%v) of ring is unexpected. (case=%d)", shr.GetActiveTargets(), shr.InPanic(), i)
+			t.FailNow()
+		}
+		if len(events) != len(c.expectedEvents) {
+			t.Errorf("The events %v should be %v. (case=%d)", events, c.expectedEvents, i)
+			t.FailNow()
+		}
+		for j, event := range events {
+			if event.Type != c.expectedEvents[j] || event.HealthyTargets != c.healthyNumber || event.TotalTargets != targetNumber {
+				t.Errorf("The event %+v is unexpected. (case=%d)", event, i)
+				t.FailNow()
+			}
+		}
+	}
+	if _, err := NewSimpleHashRing(WithPanicThreshold(101)); !errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("Creating hash ring with panic threshold over 100 should be '%v'. (but %v)", ErrInvalidArgument, err)
+		t.FailNow()
+	}
+}
+
+func TestSimpleHashRingWithPanicThresholdAndFall(t *testing.T) {
+	shr, err := NewSimpleHashRing(WithShadowNumber(10), WithCheckThresholds(1, 3), WithPanicThreshold(50))
+	if err != nil {
+		t.Errorf("Creating hash ring Error: %s", err)
+		t.FailNow()
+	}
+	targetNumber := 4
+	for i := 0; i < targetNumber; i++ {
+		shr.AddTarget("10.11.5." + strconv.Itoa(i) + ":2181")
+	}
+	deadTarget := "10.11.5.3:2181"
+	for i := 0; i < 3; i++ {
+		shr.Check(func(target string) bool { return target != deadTarget })
+	}
+	if pendingTargets := shr.GetPendingTargets(); len(pendingTargets) != 1 || pendingTargets[0] != deadTarget {
+		t.Errorf("The pending targets %v should be [%s].", pendingTargets, deadTarget)
+		t.FailNow()
+	}
+	// The targets which fail once are still healthy before the fall threshold.
+	shr.Check(func(target string) bool { return target == "10.11.5.2:2181" })
+	if shr.InPanic() {
+		t.Errorf("The ring should not enter panic mode by one failed check.")
+		t.FailNow()
+	}
+	if pendingTargets := shr.GetPendingTargets(); len(pendingTargets) != 1 || pendingTargets[0] != deadTarget {
+		t.Errorf("The pending targets %v should be [%s].", pendingTargets, deadTarget)
+		t.FailNow()
+	}
+}
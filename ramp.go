@@ -0,0 +1,241 @@
+package chash4go
+
+import (
+	"math"
+	"time"
+)
+
+// The defaults of weight ramping.
+const (
+	DEFAULT_RAMP_WINDOW     time.Duration = time.Minute
+	DEFAULT_RAMP_STEPS      int           = 10
+	DEFAULT_INITIAL_PERCENT float64       = 10
+)
+
+/*
+ * The time-based ramping of the weights of targets. The target which is added
+ * or re-admitted starts with 'InitialPercent' of its weight, and ramps up to
+ * the full weight over 'Window'. The draining target ramps down to zero over
+ * 'Window', and then it is taken out of the ring and marked down as drained. The weight is changed in
+ * 'Steps' steps, and only the shadows beyond the smaller shadow count of
+ * two steps are moved at each step.
+ */
+type WeightRamp struct {
+	Window         time.Duration
+	Steps          int
+	InitialPercent float64
+}
+
+// Fill the zero fields with the defaults, and validate the ramp.
+func (self *WeightRamp) normalize() error {
+	if self.Window == 0 {
+		self.Window = DEFAULT_RAMP_WINDOW
+	}
+	if self.Steps == 0 {
+		self.Steps = DEFAULT_RAMP_STEPS
+	}
+	if self.InitialPercent == 0 {
+		self.InitialPercent = DEFAULT_INITIAL_PERCENT
+	}
+	switch {
+	case self.Window < 0:
+		return &ArgumentError{"Window", self.Window, "It should be greater than 0."}
+	case self.Steps < 0:
+		return &ArgumentError{"Steps", self.Steps, "It should be greater than 0."}
+	case self.InitialPercent < 0 || self.InitialPercent > 100:
+		return &ArgumentError{"InitialPercent", self.InitialPercent, "It should be in (0, 100]."}
+	}
+	return nil
+}
+
+// The ramp of a target from the start percent to 100 (or 0 if draining).
+type targetRamp struct {
+	startTime    time.Time
+	startPercent float64
+	drain        bool
+}
+
+// Get the percent of weight at the time, and whether the ramp is done.
+func (self *targetRamp) getPercent(config *WeightRamp, now time.Time) (float64, bool) {
+	endPercent := float64(100)
+	if self.drain {
+		endPercent = 0
+	}
+	step := int(int64(now.Sub(self.startTime)) * int64(config.Steps) / int64(config.Window))
+	if step >= config.Steps {
+		return endPercent, true
+	}
+	if step < 0 {
+		step = 0
+	}
+	return self.startPercent + (endPercent-self.startPercent)*float64(step)/float64(config.Steps), false
+}
+
+// Get the shadow count of the percent of the full shadow count. It is at least 1
+// unless the percent is zero.
+func getRampShadowCount(fullShadowCount int, percent float64) int {
+	if percent <= 0 {
+		return 0
+	}
+	shadowCount := int(math.Round(float64(fullShadowCount) * percent / 100))
+	if shadowCount < 1 {
+		shadowCount = 1
+	}
+	if shadowCount > fullShadowCount {
+		shadowCount = fullShadowCount
+	}
+	return shadowCount
+}
+
+// Get the shadow count of target with the weight, which is reduced by its ramp.
+// It should be called with the lock held.
+func (self *SimpleHashRing) getRampedShadowCount(target string, weight uint16, now time.Time) int {
+	shadowCount := self.getShadowCount(weight)
+	if ramp, exists := self.rampMap[target]; exists {
+		percent, _ := ramp.getPercent(self.weightRamp, now)
+		if rampShadowCount := getRampShadowCount(shadowCount, percent); rampShadowCount > 0 {
+			return rampShadowCount
+		}
+		return 1
+	}
+	return shadowCount
+}
+
+// Get the weight of target in proportion to its shadows in the ring. It is the
+// configured weight unless the target is ramping, and at least 1.
+// It should be called with the lock held.
+func (self *SimpleHashRing) getEffectiveWeight(target string) uint16 {
+	weight := self.weightMap[target]
+	if _, ramping := self.rampMap[target]; !ramping {
+		return weight
+	}
+	fullShadowCount := self.getShadowCount(weight)
+	effectiveWeight := math.Round(float64(weight) * float64(self.shadowCountMap[target]) / float64(fullShadowCount))
+	if effectiveWeight < 1 {
+		return 1
+	}
+	if effectiveWeight > float64(weight) {
+		return weight
+	}
+	return uint16(effectiveWeight)
+}
+
+// Start the ramp of target, and resize its shadows to the start of ramp. The
+// node ring should be copied before if the target is active. The ramp up of
+// the fully weighted target starts with the initial percent, and the other
+// ramps start with the current percent. It should be called with the lock held.
+func (self *SimpleHashRing) startRamp(target string, now time.Time, drain bool) bool {
+	if self.weightRamp == nil || self.Compatibility == LIBKETAMA {
+		return false
+	}
+	percent := float64(self.shadowCountMap[target]) * 100 / float64(self.getShadowCount(self.weightMap[target]))
+	if percent > 100 {
+		percent = 100
+	}
+	if !drain && (percent >= 100 || percent < self.weightRamp.InitialPercent) {
+		percent = self.weightRamp.InitialPercent
+	}
+	return self.startRampFrom(target, now, percent, drain)
+}
+
+// Start the ramp of target from the percent. It should be called with the lock held.
+func (self *SimpleHashRing) startRampFrom(target string, now time.Time, percent float64, drain bool) bool {
+	if self.weightRamp == nil || self.Compatibility == LIBKETAMA {
+		return false
+	}
+	self.rampMap[target] = &targetRamp{startTime: now, startPercent: percent, drain: drain}
+	self.resizeShadows(target, getRampShadowCount(self.getShadowCount(self.weightMap[target]), percent))
+	self.scheduleRamps()
+	return true
+}
+
+// Put the pending target back into the node ring, and ramp it up from the
+// initial percent. The node ring should be copied before. Nothing is changed
+// if its nodes collide. It should be called with the lock held.
+func (self *SimpleHashRing) readmitTarget(target string, now time.Time) bool {
+	validNodeKeys, done := self.addNodesOfTarget(self.nodeRing, target, self.pendingTargetMap[target])
+	if !done {
+		return false
+	}
+	self.targetMap[target] = validNodeKeys
+	delete(self.pendingTargetMap, target)
+	if self.weightRamp != nil {
+		self.startRampFrom(target, now, self.weightRamp.InitialPercent, false)
+	}
+	return true
+}
+
+// Schedule the next step of ramps if there is any.
+// It should be called with the lock held.
+func (self *SimpleHashRing) scheduleRamps() {
+	if len(self.rampMap) == 0 || self.rampTimer != nil {
+		return
+	}
+	interval := self.weightRamp.Window / time.Duration(self.weightRamp.Steps)
+	self.rampTimer = time.AfterFunc(interval, func() {
+		self.advanceRamps(time.Now())
+	})
+}
+
+// Move the shadows of the ramping targets to the current step. The draining
+// target is taken out of the ring at the end of its ramp.
+func (self *SimpleHashRing) advanceRamps(now time.Time) (err error) {
+	self.changeLock.Lock()
+	defer self.changeLock.Unlock()
+	defer recoverError("advance ramps", &err, self.getLogger())
+	if self.rampTimer != nil {
+		self.rampTimer.Stop()
+		self.rampTimer = nil
+	}
+	if self.status != BUILDED {
+		return ErrNotBuilt
+	}
+	changed := false
+	for target, ramp := range self.rampMap {
+		nodeKeys, active := self.targetMap[target]
+		if !active {
+			delete(self.rampMap, target)
+			continue
+		}
+		percent, done := ramp.getPercent(self.weightRamp, now)
+		shadowCount := getRampShadowCount(self.getShadowCount(self.weightMap[target]), percent)
+		if shadowCount != self.shadowCountMap[target] || done && ramp.drain {
+			if !changed {
+				self.nodeRing = self.nodeRing.Clone()
+				changed = true
+			}
+		}
+		if done && ramp.drain {
+			self.removeNodeByKeys(self.nodeRing, nodeKeys)
+			self.pendingTargetMap[target] = nodeKeys
+			delete(self.targetMap, target)
+			self.setTargetState(target, TARGET_DOWN, DRAINED_REASON, true, now)
+		} else if shadowCount != self.shadowCountMap[target] {
+			self.resizeShadows(target, shadowCount)
+		}
+		if done {
+			delete(self.rampMap, target)
+		}
+	}
+	if changed {
+		self.publish()
+	}
+	self.scheduleRamps()
+	return nil
+}
+
+// Get the percent of the weight of target which is in the ring. It is 100
+// if the target is not ramping, and 0 if the target is out of the ring.
+func (self *SimpleHashRing) GetRampPercent(target string) float64 {
+	self.changeLock.RLock()
+	defer self.changeLock.RUnlock()
+	ramp, exists := self.rampMap[target]
+	if !exists {
+		if _, active := self.targetMap[target]; !active {
+			return 0
+		}
+		return 100
+	}
+	percent, _ := ramp.getPercent(self.weightRamp, time.Now())
+	return percent
+}
@@ -0,0 +1,182 @@
+package chash4go
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestSimpleHashRingWithWeightRamp(t *testing.T) {
+	window := time.Hour
+	shr, err := NewSimpleHashRing(WithShadowNumber(100), WithWeightRamp(WeightRamp{Window: window}))
+	if err != nil {
+		t.Errorf("Creating hash ring Error: %s", err)
+		t.FailNow()
+	}
+	defer shr.Destroy()
+	servers := [...]string{"10.11.156.71:2181", "10.11.5.145:2181", "10.11.5.164:2181"}
+	for _, s := range servers {
+		shr.AddTarget(s)
+	}
+	shr.advanceRamps(time.Now().Add(window))
+	keyNumber := 2000
+	getTargets := func() map[string]string {
+		targets := make(map[string]string, keyNumber)
+		for i := 0; i < keyNumber; i++ {
+			key := "key-" + strconv.Itoa(i)
+			targets[key], _ = shr.GetTarget(key)
+		}
+		return targets
+	}
+	getShadowCount := func(target string) int {
+		shr.changeLock.RLock()
+		defer shr.changeLock.RUnlock()
+		return shr.shadowCountMap[target]
+	}
+	// The keys only move to the new target, and the moved keys grow at each step.
+	newTarget := "192.168.106.63:2181"
+	before := getTargets()
+	begin := time.Now()
+	shr.AddTarget(newTarget)
+	movedKeys := make(map[string]bool)
+	cases := []struct {
+		elapsed             time.Duration
+		expectedShadowCount int
+	}{
+		{0, 10},
+		{window/10 + time.Second, 19},
+		{window/2 + time.Second, 55},
+		{window + time.Second, 100},
+	}
+	for i, c := range cases {
+		if c.elapsed > 0 {
+			shr.advanceRamps(begin.Add(c.elapsed))
+		}
+		if shadowCount := getShadowCount(newTarget); shadowCount != c.expectedShadowCount {
+			t.Errorf("The shadow count %d of target '%s' should be %d. (case=%d)", shadowCount, newTarget, c.expectedShadowCount, i)
+			t.FailNow()
+		}
+		// The weight in effect follows the shadows, while the configured weight is unchanged.
+		if weight, _ := shr.GetEffectiveWeight(newTarget); int(weight) != c.expectedShadowCount {
+			t.Errorf("The effective weight %d of target '%s' should be %d. (case=%d)", weight, newTarget, c.expectedShadowCount, i)
+			t.FailNow()
+		}
+		if weights := shr.GetEffectiveWeights(); int(weights[newTarget]) != c.expectedShadowCount || weights[servers[0]] != DEFAULT_WEIGHT {
+			t.Errorf("The effective weights %v are unexpected. (case=%d)", weights, i)
+			t.FailNow()
+		}
+		if weight, _ := shr.GetWeight(newTarget); weight != DEFAULT_WEIGHT {
+			t.Errorf("The weight %d of target '%s' should be %d. (case=%d)", weight, newTarget, DEFAULT_WEIGHT, i)
+			t.FailNow()
+		}
+		currentMovedKeys := make(map[string]bool)
+		for key, target := range getTargets() {
+			if target != before[key] {
+				if target != newTarget {
+					t.Errorf("The key '%s' should not move from '%s' to '%s'. (case=%d)", key, before[key], target, i)
+					t.FailNow()
+				}
+				currentMovedKeys[key] = true
+			}
+		}
+		for key := range movedKeys {
+			if !currentMovedKeys[key] {
+				t.Errorf("The moved key '%s' should stay on target '%s'. (case=%d)", key, newTarget, i)
+				t.FailNow()
+			}
+		}
+		if len(currentMovedKeys) <= len(movedKeys) {
+			t.Errorf("The moved keys should grow. (%d -> %d, case=%d)", len(movedKeys), len(currentMovedKeys), i)
+			t.FailNow()
+		}
+		movedKeys = currentMovedKeys
+	}
+	if percent := shr.GetRampPercent(newTarget); percent != 100 {
+		t.Errorf("The ramp percent %f of target '%s' should be 100.", percent, newTarget)
+		t.FailNow()
+	}
+	// The draining target ramps down, and then it is taken out of the ring.
+	begin = time.Now()
+	if err := shr.Drain(newTarget, "decommission"); err != nil {
+		t.Errorf("Draining target '%s' Error: %s", newTarget, err)
+		t.FailNow()
+	}
+	if _, active := shr.GetWeight(newTarget); !active {
+		t.Errorf("The draining target '%s' should stay in the ring.", newTarget)
+		t.FailNow()
+	}
+	shr.advanceRamps(begin.Add(window/2 + time.Second))
+	if shadowCount := getShadowCount(newTarget); shadowCount != 50 {
+		t.Errorf("The shadow count %d of draining target '%s' should be 50.", shadowCount, newTarget)
+		t.FailNow()
+	}
+	shr.advanceRamps(begin.Add(window + time.Second))
+	if info := shr.Targets()[3]; info.State != TARGET_DOWN || info.Reason != DRAINED_REASON || !info.Manual {
+		t.Errorf("The drained target '%s' should be down. (info=%+v)", newTarget, info)
+		t.FailNow()
+	}
+	if _, active := shr.GetWeight(newTarget); active {
+		t.Errorf("The drained target '%s' should be out of the ring. (info=%+v)", newTarget, shr.Targets()[3])
+		t.FailNow()
+	}
+	for key, target := range getTargets() {
+		if target != before[key] {
+			t.Errorf("The key '%s' should move back to '%s'. (but %s)", key, before[key], target)
+			t.FailNow()
+		}
+	}
+	// The re-admitted target starts with the initial percent.
+	shr.MarkUp(newTarget)
+	if shadowCount := getShadowCount(newTarget); shadowCount != 10 {
+		t.Errorf("The shadow count %d of re-admitted target '%s' should be 10.", shadowCount, newTarget)
+		t.FailNow()
+	}
+	if _, err := NewSimpleHashRing(WithWeightRamp(WeightRamp{InitialPercent: 120})); !errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("Creating hash ring with initial percent over 100 should be '%v'. (but %v)", ErrInvalidArgument, err)
+		t.FailNow()
+	}
+}
+
+func TestSimpleHashRingForDrainTimer(t *testing.T) {
+	window := 200 * time.Millisecond
+	shr, _ := NewSimpleHashRing(WithShadowNumber(10), WithWeightRamp(WeightRamp{Window: window, Steps: 4}))
+	defer shr.Destroy()
+	servers := [...]string{"10.11.5.145:2181", "10.11.5.164:2181"}
+	for _, s := range servers {
+		shr.AddTarget(s)
+	}
+	shr.advanceRamps(time.Now().Add(window))
+	shr.Drain(servers[0], "decommission")
+	if info := shr.Targets()[0]; info.State != TARGET_DRAINING || info.Reason != "decommission" {
+		t.Errorf("The target '%s' should be draining. (info=%+v)", servers[0], info)
+		t.FailNow()
+	}
+	time.Sleep(window + 100*time.Millisecond)
+	if info := shr.Targets()[0]; info.State != TARGET_DOWN || info.Reason != DRAINED_REASON {
+		t.Errorf("The target '%s' should be down after the drain window. (info=%+v)", servers[0], info)
+		t.FailNow()
+	}
+	if pendingTargets := shr.GetPendingTargets(); len(pendingTargets) != 1 || pendingTargets[0] != servers[0] {
+		t.Errorf("The pending targets %v should be [%s].", pendingTargets, servers[0])
+		t.FailNow()
+	}
+}
+
+func TestSimpleHashRingForRampTimer(t *testing.T) {
+	shr, _ := NewSimpleHashRing(WithShadowNumber(10), WithWeightRamp(WeightRamp{Window: 100 * time.Millisecond, Steps: 5}))
+	defer shr.Destroy()
+	target := "10.11.5.145:2181"
+	shr.AddTarget(target)
+	shr.Check(func(server string) bool { return false })
+	shr.Check(func(server string) bool { return true })
+	if percent := shr.GetRampPercent(target); percent >= 100 {
+		t.Errorf("The re-admitted target '%s' should be ramping. (percent=%f)", target, percent)
+		t.FailNow()
+	}
+	time.Sleep(300 * time.Millisecond)
+	if percent := shr.GetRampPercent(target); percent != 100 {
+		t.Errorf("The ramp of target '%s' should be done by the timer. (percent=%f)", target, percent)
+		t.FailNow()
+	}
+}
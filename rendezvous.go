@@ -0,0 +1,272 @@
+package chash4go
+
+import (
+	"math"
+	"sort"
+	"sync"
+)
+
+// Get the score of target for key by the weighted rendezvous hashing.
+// The score is '-weight / ln(h)' where 'h' is the uniform number in (0, 1)
+// which is mixed from the hashes of key & target.
+func GetRendezvousScore(keyHash uint64, targetHash uint64, weight uint16) float64 {
+	h := keyHash ^ targetHash
+	h ^= h >> 30
+	h *= 0xbf58476d1ce4e5b9
+	h ^= h >> 27
+	h *= 0x94d049bb133111eb
+	h ^= h >> 31
+	uniform := (float64(h>>11) + 0.5) / float64(uint64(1)<<53)
+	return -float64(weight) / math.Log(uniform)
+}
+
+type rendezvousTarget struct {
+	hash   uint64
+	weight uint16
+}
+
+/*
+ * A hash ring based on the rendezvous (highest random weight) hashing.
+ * Every target is scored for each key, and the targets with higher
+ * scores are preferred.
+ */
+type RendezvousRing struct {
+	targetMap        map[string]rendezvousTarget
+	pendingTargetMap map[string]rendezvousTarget
+	changeLock       sync.RWMutex
+	status           HashRingStatus
+	ringLogger
+	ringChecker
+}
+
+var _ HashRing = (*RendezvousRing)(nil)
+
+func (self *RendezvousRing) initialize() {
+	self.targetMap = make(map[string]rendezvousTarget)
+	self.pendingTargetMap = make(map[string]rendezvousTarget)
+	self.status = INITIALIZED
+}
+
+// The shadow number is ignored since there is no virtual node in rendezvous ring.
+func (self *RendezvousRing) Build(shadowNumber uint16) error {
+	self.changeLock.Lock()
+	defer self.changeLock.Unlock()
+	switch self.status {
+	case "", UNINITIALIZED, DESTROYED:
+		self.initialize()
+		fallthrough
+	case INITIALIZED:
+		self.status = BUILDED
+	default:
+		return ErrAlreadyBuilt
+	}
+	return nil
+}
+
+func (self *RendezvousRing) Destroy() error {
+	self.StopCheck()
+	self.changeLock.Lock()
+	defer self.changeLock.Unlock()
+	switch self.status {
+	case INITIALIZED, BUILDED:
+		self.targetMap = nil
+		self.pendingTargetMap = nil
+		self.status = DESTROYED
+	default:
+		return ErrNotBuilt
+	}
+	return nil
+}
+
+func (self *RendezvousRing) Status() HashRingStatus {
+	self.changeLock.RLock()
+	defer self.changeLock.RUnlock()
+	if len(self.status) == 0 {
+		return UNINITIALIZED
+	}
+	return self.status
+}
+
+func (self *RendezvousRing) Check(nodeCheckFunc NodeCheckFunc) (err error) {
+	defer recoverError("check rendezvous ring", &err, self.getLogger())
+	self.changeLock.RLock()
+	if self.status != BUILDED {
+		self.changeLock.RUnlock()
+		return ErrNotBuilt
+	}
+	targets := make([]string, 0, len(self.targetMap)+len(self.pendingTargetMap))
+	for target := range self.targetMap {
+		targets = append(targets, target)
+	}
+	for target := range self.pendingTargetMap {
+		targets = append(targets, target)
+	}
+	self.changeLock.RUnlock()
+	for _, target := range targets {
+		valid := nodeCheckFunc(target)
+		self.changeLock.Lock()
+		if rt, exists := self.targetMap[target]; exists && !valid {
+			self.getLogger().Infof("Removing invalid target '%s'...", target)
+			self.pendingTargetMap[target] = rt
+			delete(self.targetMap, target)
+		} else if rt, exists := self.pendingTargetMap[target]; exists && valid {
+			self.getLogger().Infof("Adding valid target '%s'...", target)
+			self.targetMap[target] = rt
+			delete(self.pendingTargetMap, target)
+		}
+		self.changeLock.Unlock()
+	}
+	return nil
+}
+
+func (self *RendezvousRing) StartCheck(nodeCheckFunc NodeCheckFunc, intervalSeconds uint16) (bool, error) {
+	if self.Status() != BUILDED {
+		return false, ErrNotBuilt
+	}
+	checkFunc := func() {
+		err := self.Check(nodeCheckFunc)
+		if err != nil {
+			self.getLogger().Errorf("Rendezvous ring checking is FAILING: %s", err)
+		}
+	}
+	return self.startChecker(checkFunc, intervalSeconds, self.getLogger()), nil
+}
+
+func (self *RendezvousRing) AddTarget(target string) error {
+	return self.AddWeightedTarget(target, DEFAULT_WEIGHT)
+}
+
+func (self *RendezvousRing) AddWeightedTarget(target string, weight uint16) error {
+	self.changeLock.Lock()
+	defer self.changeLock.Unlock()
+	if self.status != BUILDED {
+		return ErrNotBuilt
+	}
+	if weight == 0 {
+		return &TargetError{"add target", target, ErrInvalidWeight}
+	}
+	_, active := self.targetMap[target]
+	_, pending := self.pendingTargetMap[target]
+	if active || pending {
+		return &TargetError{"add target", target, ErrTargetExists}
+	}
+	self.targetMap[target] = rendezvousTarget{GetHash64(target), weight}
+	return nil
+}
+
+func (self *RendezvousRing) SetWeight(target string, weight uint16) error {
+	self.changeLock.Lock()
+	defer self.changeLock.Unlock()
+	if self.status != BUILDED {
+		return ErrNotBuilt
+	}
+	if weight == 0 {
+		return &TargetError{"set weight", target, ErrInvalidWeight}
+	}
+	if rt, exists := self.targetMap[target]; exists {
+		rt.weight = weight
+		self.targetMap[target] = rt
+		return nil
+	}
+	if rt, exists := self.pendingTargetMap[target]; exists {
+		rt.weight = weight
+		self.pendingTargetMap[target] = rt
+		return nil
+	}
+	return &TargetError{"set weight", target, ErrUnknownTarget}
+}
+
+func (self *RendezvousRing) RemoveTarget(target string) error {
+	self.changeLock.Lock()
+	defer self.changeLock.Unlock()
+	if self.status != BUILDED {
+		return ErrNotBuilt
+	}
+	_, active := self.targetMap[target]
+	_, pending := self.pendingTargetMap[target]
+	if !active && !pending {
+		return &TargetError{"remove target", target, ErrUnknownTarget}
+	}
+	delete(self.targetMap, target)
+	delete(self.pendingTargetMap, target)
+	return nil
+}
+
+func (self *RendezvousRing) ContainsTarget(target string) bool {
+	self.changeLock.RLock()
+	defer self.changeLock.RUnlock()
+	_, active := self.targetMap[target]
+	_, pending := self.pendingTargetMap[target]
+	return active || pending
+}
+
+func (self *RendezvousRing) GetActiveTargets() []string {
+	self.changeLock.RLock()
+	defer self.changeLock.RUnlock()
+	targets := make([]string, 0, len(self.targetMap))
+	for target := range self.targetMap {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+	return targets
+}
+
+func (self *RendezvousRing) GetPendingTargets() []string {
+	self.changeLock.RLock()
+	defer self.changeLock.RUnlock()
+	targets := make([]string, 0, len(self.pendingTargetMap))
+	for target := range self.pendingTargetMap {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+	return targets
+}
+
+func (self *RendezvousRing) GetTarget(key string) (string, error) {
+	results, err := self.GetTargets(key, 1)
+	if err != nil {
+		return "", err
+	}
+	if len(results) == 0 {
+		return "", nil
+	}
+	return results[0], nil
+}
+
+// Get the targets in the descending order of their scores for key.
+func (self *RendezvousRing) GetTargets(key string, number int) ([]string, error) {
+	self.changeLock.RLock()
+	defer self.changeLock.RUnlock()
+	if self.status != BUILDED {
+		return nil, ErrNotBuilt
+	}
+	results := make([]string, 0)
+	if len(key) == 0 {
+		return results, nil
+	}
+	targetNumber := len(self.targetMap)
+	if targetNumber == 0 {
+		return nil, ErrEmptyRing
+	}
+	if number <= 0 {
+		number = 1
+	}
+	if number > targetNumber {
+		number = targetNumber
+	}
+	keyHash := GetHash64(key)
+	targets := make([]string, 0, targetNumber)
+	scores := make(map[string]float64, targetNumber)
+	for target, rt := range self.targetMap {
+		targets = append(targets, target)
+		scores[target] = GetRendezvousScore(keyHash, rt.hash, rt.weight)
+	}
+	sort.Slice(targets, func(i, j int) bool {
+		si, sj := scores[targets[i]], scores[targets[j]]
+		if si != sj {
+			return si > sj
+		}
+		return targets[i] < targets[j]
+	})
+	return append(results, targets[:number]...), nil
+}
@@ -0,0 +1,109 @@
+package chash4go
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestRendezvousRing(t *testing.T) {
+	servers := [...]string{"10.11.156.71:2181", "10.11.5.145:2181", "10.11.5.164:2181", "192.168.106.63:2181", "192.168.106.64:2181"}
+	var hashRing HashRing = &RendezvousRing{}
+	rr := hashRing.(*RendezvousRing)
+	err := rr.Build(0)
+	if err != nil {
+		t.Errorf("Build hash ring Error: %s", err)
+		t.FailNow()
+	}
+	for _, s := range servers {
+		if err := rr.AddTarget(s); err != nil {
+			t.Errorf("Adding server Error: %s", err)
+			t.FailNow()
+		}
+	}
+	keyNumber := 10000
+	before := make(map[string][]string, keyNumber)
+	for i := 0; i < keyNumber; i++ {
+		key := "key-" + strconv.Itoa(i)
+		targets, err := rr.GetTargets(key, len(servers))
+		if err != nil || len(targets) != len(servers) {
+			t.Errorf("Getting targets of key '%s' is FAILING. (targets=%v, err=%v)", key, targets, err)
+			t.FailNow()
+		}
+		target, _ := rr.GetTarget(key)
+		if target != targets[0] {
+			t.Errorf("The target '%s' of key '%s' should be the first of %v.", target, key, targets)
+			t.FailNow()
+		}
+		before[key] = targets
+	}
+	// begin - test about remove target
+	removedServer := servers[3]
+	if err := rr.RemoveTarget(removedServer); err != nil {
+		t.Errorf("Removing target '%s' Error: %s", removedServer, err)
+		t.FailNow()
+	}
+	for key, targets := range before {
+		expectedTargets := make([]string, 0, len(targets)-1)
+		for _, target := range targets {
+			if target != removedServer {
+				expectedTargets = append(expectedTargets, target)
+			}
+		}
+		afterTargets, _ := rr.GetTargets(key, len(servers))
+		for i, target := range afterTargets {
+			if target != expectedTargets[i] {
+				t.Errorf("The targets %v of key '%s' should be %v.", afterTargets, key, expectedTargets)
+				t.FailNow()
+			}
+		}
+	}
+	// end - test about remove target
+	// begin - test about check
+	invalidServer := servers[0]
+	rr.Check(func(target string) bool { return target != invalidServer })
+	targets, _ := rr.GetTargets("chash_test", len(servers))
+	if len(targets) != len(servers)-2 {
+		t.Errorf("The length of targets %v should be %v.", targets, len(servers)-2)
+		t.FailNow()
+	}
+	for _, target := range targets {
+		if target == invalidServer {
+			t.Errorf("The invalid target '%s' should not be in %v.", target, targets)
+			t.FailNow()
+		}
+	}
+	rr.Check(func(target string) bool { return true })
+	targets, _ = rr.GetTargets("chash_test", len(servers))
+	if len(targets) != len(servers)-1 {
+		t.Errorf("The length of targets %v should be %v.", targets, len(servers)-1)
+		t.FailNow()
+	}
+	// end - test about check
+	if err := rr.Destroy(); err != nil || rr.Status() != DESTROYED {
+		t.Errorf("Destroy hash ring is FAILING. (err=%v)", err)
+		t.FailNow()
+	}
+}
+
+func TestRendezvousRingWithWeight(t *testing.T) {
+	rr := RendezvousRing{}
+	rr.Build(0)
+	weights := map[string]uint16{"10.11.5.145:2181": 100, "10.11.5.164:2181": 300}
+	for target, weight := range weights {
+		if err := rr.AddWeightedTarget(target, weight); err != nil {
+			t.Errorf("Adding weighted target '%s' Error: %s", target, err)
+			t.FailNow()
+		}
+	}
+	counts := make(map[string]int)
+	for i := 0; i < 20000; i++ {
+		target, _ := rr.GetTarget("key-" + strconv.Itoa(i))
+		counts[target]++
+	}
+	ratio := float64(counts["10.11.5.164:2181"]) / float64(counts["10.11.5.145:2181"])
+	t.Logf("The key counts: %v (ratio=%f)", counts, ratio)
+	if ratio < 2.7 || ratio > 3.3 {
+		t.Errorf("The ratio '%f' of key counts should be near to 3.", ratio)
+		t.FailNow()
+	}
+}
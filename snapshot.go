@@ -0,0 +1,92 @@
+package chash4go
+
+// The immutable view of a simple hash ring. The lookups on a snapshot are
+// consistent with each other even if the ring is changed meanwhile.
+// The nil snapshot is the view of an unbuilded ring.
+type RingSnapshot struct {
+	nodeRing       *NodeRing
+	targets        []string
+	keyHasher      Hasher
+	keyHashVersion KeyHashVersion
+}
+
+func (self *RingSnapshot) GetTarget(key string) (string, error) {
+	results, err := self.GetTargets(key, 1)
+	if err != nil {
+		return "", err
+	}
+	if len(results) == 0 {
+		return "", nil
+	}
+	return results[0], nil
+}
+
+func (self *RingSnapshot) GetTargets(key string, number int) ([]string, error) {
+	return self.getTargetsBy(key, number, nil)
+}
+
+// Get the distinct targets of key in the order of ring. The targets which are
+// not accepted are skipped. All targets are accepted if the function is nil.
+func (self *RingSnapshot) getTargetsBy(key string, number int, accept func(target string) bool) ([]string, error) {
+	if self == nil {
+		return nil, ErrNotBuilt
+	}
+	results := make([]string, 0)
+	if len(key) == 0 {
+		return results, nil
+	}
+	if len(self.targets) == 0 || self.nodeRing.Len() == 0 {
+		return nil, ErrEmptyRing
+	}
+	if number <= 0 {
+		number = 1
+	}
+	targetNumber := len(self.targets)
+	if number > targetNumber {
+		number = targetNumber
+	}
+	currentKeyHash := self.getKeyHash(key)
+	visited := make([]string, 0, number)
+	for i := 0; len(results) < number && len(visited) < targetNumber && i < self.nodeRing.Len(); i++ {
+		matchedNode := self.nodeRing.Next(currentKeyHash)
+		nodeHash := matchedNode.Key
+		target := matchedNode.Target
+		currentKeyHash = nodeHash + 1
+		contain := false
+		for _, t := range visited {
+			if t == target {
+				contain = true
+				break
+			}
+		}
+		if contain {
+			continue
+		}
+		visited = append(visited, target)
+		if accept == nil || accept(target) {
+			results = append(results, target)
+		}
+	}
+	return results, nil
+}
+
+// Get the valid targets in the snapshot.
+func (self *RingSnapshot) GetActiveTargets() []string {
+	if self == nil {
+		return make([]string, 0)
+	}
+	targets := make([]string, len(self.targets))
+	copy(targets, self.targets)
+	return targets
+}
+
+func (self *RingSnapshot) getKeyHash(key string) uint64 {
+	return getKeyHashByVersion(self.keyHasher, self.keyHashVersion, key)
+}
+
+func getKeyHashByVersion(hasher Hasher, version KeyHashVersion, key string) uint64 {
+	if version == KEY_HASH_V1 {
+		return GetHashForKeyByHasher(hasher, key)
+	}
+	return GetUniformHashForKeyByHasher(hasher, key)
+}
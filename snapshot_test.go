@@ -0,0 +1,69 @@
+package chash4go
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+)
+
+func TestRingSnapshot(t *testing.T) {
+	servers := [...]string{"10.11.156.71:2181", "10.11.5.145:2181", "10.11.5.164:2181", "192.168.106.63:2181", "192.168.106.64:2181"}
+	shr := SimpleHashRing{}
+	if _, err := shr.Snapshot().GetTarget("chash_test"); !errors.Is(err, ErrNotBuilt) {
+		t.Errorf("Getting target from snapshot of unbuilded ring should be '%v'. (but %v)", ErrNotBuilt, err)
+		t.FailNow()
+	}
+	shr.Build(500)
+	for _, s := range servers {
+		shr.AddTarget(s)
+	}
+	snapshot := shr.Snapshot()
+	keyNumber := 1000
+	before := make(map[string]string, keyNumber)
+	for i := 0; i < keyNumber; i++ {
+		key := "key-" + strconv.Itoa(i)
+		before[key], _ = snapshot.GetTarget(key)
+	}
+	removedTarget := servers[2]
+	if err := shr.RemoveTarget(removedTarget); err != nil {
+		t.Errorf("Removing target '%s' Error: %s", removedTarget, err)
+		t.FailNow()
+	}
+	if len(snapshot.GetActiveTargets()) != len(servers) {
+		t.Errorf("The active targets %v of pinned snapshot should not be changed.", snapshot.GetActiveTargets())
+		t.FailNow()
+	}
+	moved := 0
+	for key, target := range before {
+		pinnedTarget, _ := snapshot.GetTarget(key)
+		if pinnedTarget != target {
+			t.Errorf("The target '%s' of key '%s' in pinned snapshot should be '%s'.", pinnedTarget, key, target)
+			t.FailNow()
+		}
+		currentTarget, _ := shr.GetTarget(key)
+		if currentTarget == removedTarget {
+			t.Errorf("The key '%s' should not be mapped to removed target '%s'.", key, removedTarget)
+			t.FailNow()
+		}
+		if currentTarget != target {
+			if target != removedTarget {
+				t.Errorf("The key '%s' should not move from '%s' to '%s'.", key, target, currentTarget)
+				t.FailNow()
+			}
+			moved++
+		}
+	}
+	if moved == 0 {
+		t.Errorf("The keys of removed target '%s' should be moved.", removedTarget)
+		t.FailNow()
+	}
+	shr.Destroy()
+	if shr.Snapshot() != nil {
+		t.Errorf("The snapshot of destroyed ring should be nil.")
+		t.FailNow()
+	}
+	if _, err := snapshot.GetTarget("chash_test"); err != nil {
+		t.Errorf("Getting target from pinned snapshot Error: %s", err)
+		t.FailNow()
+	}
+}
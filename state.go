@@ -0,0 +1,173 @@
+package chash4go
+
+import (
+	"sort"
+	"time"
+)
+
+type TargetState string
+
+// The reason of the drained target, which is safe to be removed.
+const DRAINED_REASON = "drained"
+
+// Target state
+const (
+	// The target is in the ring.
+	TARGET_ACTIVE TargetState = "ACTIVE"
+	// The target is being taken out of the ring before removal. It stays in the
+	// ring until its weight is ramped down to zero if the weight ramping is enabled,
+	// and then it is down with the reason 'DRAINED_REASON'.
+	TARGET_DRAINING TargetState = "DRAINING"
+	// The target is out of the ring since it is unhealthy, marked down or drained.
+	TARGET_DOWN TargetState = "DOWN"
+	// The target is out of the ring for maintenance.
+	TARGET_MAINTENANCE TargetState = "MAINTENANCE"
+)
+
+// The state of target which is listed by 'Targets'.
+type TargetInfo struct {
+	Target         string
+	Weight         uint16
+	State          TargetState
+	Reason         string
+	LastTransition time.Time
+	// Whether the state is set by the operator. The checks & the outlier
+	// detection do not re-admit the target in the manual state.
+	Manual bool
+}
+
+type targetStatus struct {
+	state          TargetState
+	reason         string
+	manual         bool
+	transitionTime time.Time
+}
+
+// Set the state of target. The transition time is updated only if the state
+// is changed. It should be called with the lock held.
+func (self *SimpleHashRing) setTargetState(target string, state TargetState, reason string, manual bool, now time.Time) {
+	status, exists := self.stateMap[target]
+	if !exists {
+		status = &targetStatus{}
+		self.stateMap[target] = status
+	}
+	if status.state != state {
+		status.transitionTime = now
+		self.getLogger().Infof("The state of target '%s' is changed from '%s' to '%s'. (reason=%s)", target, status.state, state, reason)
+	}
+	status.state = state
+	status.reason = reason
+	status.manual = manual
+}
+
+// Whether the state of target is set by the operator.
+// It should be called with the lock held.
+func (self *SimpleHashRing) isManualState(target string) bool {
+	status, exists := self.stateMap[target]
+	return exists && status.manual
+}
+
+// Take the target out of the ring, and set it to the manual state.
+func (self *SimpleHashRing) holdTarget(op string, target string, state TargetState, reason string) (err error) {
+	self.changeLock.Lock()
+	defer self.changeLock.Unlock()
+	defer recoverError(op, &err, self.getLogger())
+	if self.status != BUILDED {
+		return ErrNotBuilt
+	}
+	if _, exists := self.weightMap[target]; !exists {
+		return &TargetError{op, target, ErrUnknownTarget}
+	}
+	now := time.Now()
+	if nodeKeys, active := self.targetMap[target]; active {
+		self.nodeRing = self.nodeRing.Clone()
+		if state == TARGET_DRAINING && self.startRamp(target, now, true) {
+			self.getLogger().Infof("Draining target '%s'...", target)
+		} else {
+			delete(self.rampMap, target)
+			self.removeNodeByKeys(self.nodeRing, nodeKeys)
+			self.pendingTargetMap[target] = nodeKeys
+			delete(self.targetMap, target)
+		}
+		self.publish()
+	}
+	if _, active := self.targetMap[target]; !active && state == TARGET_DRAINING {
+		// The target is drained at once without the weight ramping.
+		state, reason = TARGET_DOWN, DRAINED_REASON
+	}
+	self.setTargetState(target, state, reason, true, now)
+	return nil
+}
+
+// Mark the target down. It is not re-admitted by the checks until 'MarkUp'.
+func (self *SimpleHashRing) MarkDown(target string, reason string) error {
+	return self.holdTarget("mark down", target, TARGET_DOWN, reason)
+}
+
+// Drain the target before removing it. It is down with the reason 'DRAINED_REASON'
+// once it is out of the ring, and is not re-admitted by the checks until 'MarkUp'.
+func (self *SimpleHashRing) Drain(target string, reason string) error {
+	return self.holdTarget("drain", target, TARGET_DRAINING, reason)
+}
+
+// Take the target out for maintenance. It is not re-admitted by the checks until 'MarkUp'.
+func (self *SimpleHashRing) Maintenance(target string, reason string) error {
+	return self.holdTarget("maintenance", target, TARGET_MAINTENANCE, reason)
+}
+
+// Put the target back into the ring, and hand it over to the checks.
+func (self *SimpleHashRing) MarkUp(target string) (err error) {
+	self.changeLock.Lock()
+	defer self.changeLock.Unlock()
+	defer recoverError("mark up", &err, self.getLogger())
+	if self.status != BUILDED {
+		return ErrNotBuilt
+	}
+	if _, exists := self.weightMap[target]; !exists {
+		return &TargetError{"mark up", target, ErrUnknownTarget}
+	}
+	now := time.Now()
+	if _, pending := self.pendingTargetMap[target]; pending {
+		// The ramp is started only after the nodes of target are added.
+		nodeRing := self.nodeRing
+		self.nodeRing = nodeRing.Clone()
+		if !self.readmitTarget(target, now) {
+			self.nodeRing = nodeRing
+			return &TargetError{"mark up", target, ErrKeyCollision}
+		}
+		self.publish()
+	} else if ramp, exists := self.rampMap[target]; exists && ramp.drain {
+		// The draining target ramps up again from the current weight.
+		self.nodeRing = self.nodeRing.Clone()
+		self.startRamp(target, now, false)
+		self.publish()
+	}
+	if health, exists := self.healthMap[target]; exists {
+		health.ConsecutiveFailures = 0
+		health.Reason = nil
+		health.EjectedUntil = time.Time{}
+	}
+	self.setTargetState(target, TARGET_ACTIVE, "", false, now)
+	return nil
+}
+
+// Get the states of all targets in the order of target.
+func (self *SimpleHashRing) Targets() []TargetInfo {
+	self.changeLock.RLock()
+	defer self.changeLock.RUnlock()
+	infos := make([]TargetInfo, 0, len(self.weightMap))
+	for target, weight := range self.weightMap {
+		info := TargetInfo{Target: target, Weight: weight, State: TARGET_ACTIVE}
+		if status, exists := self.stateMap[target]; exists {
+			info.State = status.state
+			info.Reason = status.reason
+			info.LastTransition = status.transitionTime
+			info.Manual = status.manual
+		}
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Target < infos[j].Target
+	})
+	return infos
+}
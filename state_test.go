@@ -0,0 +1,127 @@
+package chash4go
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSimpleHashRingWithTargetStates(t *testing.T) {
+	servers := [...]string{"10.11.156.71:2181", "10.11.5.145:2181", "10.11.5.164:2181"}
+	shr, _ := NewSimpleHashRing(WithShadowNumber(10))
+	for _, s := range servers {
+		shr.AddTarget(s)
+	}
+	for _, info := range shr.Targets() {
+		if info.State != TARGET_ACTIVE || info.Manual || info.Weight != DEFAULT_WEIGHT || info.LastTransition.IsZero() {
+			t.Errorf("The added target should be active. (info=%+v)", info)
+			t.FailNow()
+		}
+	}
+	invalidTarget := servers[2]
+	nodeCheckFunc := func(target string) bool { return target != invalidTarget }
+	begin := time.Now()
+	if err := shr.Maintenance(servers[0], "deploy"); err != nil {
+		t.Errorf("Taking target '%s' out for maintenance Error: %s", servers[0], err)
+		t.FailNow()
+	}
+	if err := shr.Drain(servers[1], "decommission"); err != nil {
+		t.Errorf("Draining target '%s' Error: %s", servers[1], err)
+		t.FailNow()
+	}
+	shr.Check(nodeCheckFunc)
+	cases := []struct {
+		target string
+		state  TargetState
+		reason string
+		manual bool
+	}{
+		{servers[0], TARGET_MAINTENANCE, "deploy", true},
+		{servers[1], TARGET_DOWN, DRAINED_REASON, true},
+		{servers[2], TARGET_DOWN, ErrUnhealthy.Error(), false},
+	}
+	checkStates := func() {
+		for i, info := range shr.Targets() {
+			c := cases[i]
+			if info.Target != c.target || info.State != c.state || info.Reason != c.reason || info.Manual != c.manual || info.LastTransition.Before(begin) {
+				t.Errorf("The state of target '%s' should be '%s' (reason=%s, manual=%v). (info=%+v)", c.target, c.state, c.reason, c.manual, info)
+				t.FailNow()
+			}
+		}
+	}
+	checkStates()
+	if len(shr.GetActiveTargets()) != 0 {
+		t.Errorf("All targets should be out of ring. (active=%v)", shr.GetActiveTargets())
+		t.FailNow()
+	}
+	// The target is re-admitted by the check, but the targets in the manual states are not.
+	invalidTarget = ""
+	shr.Check(nodeCheckFunc)
+	cases[2].state, cases[2].reason = TARGET_ACTIVE, ""
+	checkStates()
+	if err := shr.MarkDown(servers[2], "manual"); err != nil {
+		t.Errorf("Marking target '%s' down Error: %s", servers[2], err)
+		t.FailNow()
+	}
+	shr.Check(nodeCheckFunc)
+	if _, active := shr.GetWeight(servers[2]); active {
+		t.Errorf("The target '%s' which is marked down should not be re-admitted.", servers[2])
+		t.FailNow()
+	}
+	for _, s := range servers {
+		if err := shr.MarkUp(s); err != nil {
+			t.Errorf("Marking target '%s' up Error: %s", s, err)
+			t.FailNow()
+		}
+	}
+	for _, info := range shr.Targets() {
+		if info.State != TARGET_ACTIVE || info.Manual {
+			t.Errorf("The target which is marked up should be active. (info=%+v)", info)
+			t.FailNow()
+		}
+	}
+	if len(shr.GetActiveTargets()) != len(servers) {
+		t.Errorf("All targets should be in ring. (active=%v)", shr.GetActiveTargets())
+		t.FailNow()
+	}
+	if err := shr.MarkDown("10.11.5.1:2181", "manual"); !errors.Is(err, ErrUnknownTarget) {
+		t.Errorf("Marking unknown target down should be '%v'. (but %v)", ErrUnknownTarget, err)
+		t.FailNow()
+	}
+	if err := shr.RemoveTarget(servers[0]); err != nil || len(shr.Targets()) != len(servers)-1 {
+		t.Errorf("The removed target should not be listed. (err=%v, targets=%+v)", err, shr.Targets())
+		t.FailNow()
+	}
+}
+
+func TestSimpleHashRingForMarkUpCollision(t *testing.T) {
+	shr, _ := NewSimpleHashRing(WithShadowNumber(10), WithWeightRamp(WeightRamp{Window: time.Hour}))
+	defer shr.Destroy()
+	servers := [...]string{"10.11.5.145:2181", "10.11.5.164:2181"}
+	for _, s := range servers {
+		shr.AddTarget(s)
+	}
+	shr.advanceRamps(time.Now().Add(time.Hour))
+	shr.MarkDown(servers[0], "manual")
+	// The nodes of the pending target are taken by another target.
+	shr.changeLock.Lock()
+	nodeRing := shr.nodeRing
+	shr.addNodesOfTarget(nodeRing, servers[1], shr.pendingTargetMap[servers[0]])
+	shr.changeLock.Unlock()
+	if err := shr.MarkUp(servers[0]); !errors.Is(err, ErrKeyCollision) {
+		t.Errorf("Marking target '%s' up should be '%v'. (but %v)", servers[0], ErrKeyCollision, err)
+		t.FailNow()
+	}
+	shr.changeLock.RLock()
+	_, ramping := shr.rampMap[servers[0]]
+	unchanged := shr.nodeRing == nodeRing
+	shr.changeLock.RUnlock()
+	if ramping || !unchanged {
+		t.Errorf("The target '%s' which is not marked up should not ramp or change the ring.", servers[0])
+		t.FailNow()
+	}
+	if info := shr.Targets()[0]; info.State != TARGET_DOWN {
+		t.Errorf("The target '%s' should stay down. (info=%+v)", servers[0], info)
+		t.FailNow()
+	}
+}